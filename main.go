@@ -1,104 +1,538 @@
 package main
 
 import (
-	"encoding/json"
+	"context"
+	"errors"
+	"flag"
 	"fmt"
+	"io"
 	"os"
+	"os/signal"
 	"strings"
+	"time"
+
+	"rummikub-checkmate/rummikub"
+)
+
+// 終了コード
+const (
+	exitCheckmate   = 0 // 詰みあり
+	exitNoCheckmate = 1 // 詰みなし
+	exitInputError  = 2 // 入力の誤り
+	exitUnknown     = 3 // 時間切れや中断で詰みの有無が分からない
 )
 
-// JSON入力用の構造体
-type GameStateJSON struct {
-	Board [][]string `json:"board"`
-	Hand  []string   `json:"hand"`
+// verify と check サブコマンドでは、正しい解（手）なら exitCheckmate、誤りがあれば exitNoCheckmate で終了する
+
+func main() {
+	opts := rummikub.DefaultOptions()
+	initialPoints := flag.Int("initial-points", opts.Rules.InitialMeldPoints, "初手に必要な合計点数")
+	flag.BoolVar(&opts.Rules.OpeningManipulation, "opening-manipulation", opts.Rules.OpeningManipulation, "初手と同じターンに場の組み替えを許可する")
+	flag.BoolVar(&opts.Rules.FreeJokers, "free-jokers", opts.Rules.FreeJokers, "場のジョーカーを入れ替えなしで動かせるハウスルールで判定する")
+	minNumber := flag.Int("min-number", int(opts.Rules.MinNumber), "タイルの数字の最小値")
+	maxNumber := flag.Int("max-number", int(opts.Rules.MaxNumber), "タイルの数字の最大値")
+	colors := flag.String("colors", "RBYK", "使う色 (R, B, Y, K の並び)")
+	flag.IntVar(&opts.Rules.Copies, "copies", opts.Rules.Copies, "同じタイルの枚数")
+	jokers := flag.Int("jokers", opts.Rules.Jokers, "ジョーカーの枚数")
+	flag.IntVar(&opts.Rules.MinMeldSize, "min-meld", opts.Rules.MinMeldSize, "メルドの最小枚数")
+	flag.BoolVar(&opts.Rules.WrapRuns, "wrap-runs", opts.Rules.WrapRuns, "最大の数字から1に折り返すランを認める")
+	engine := flag.String("engine", opts.Engine.String(), "探索エンジン (backtrack, dlx)")
+	flag.IntVar(&opts.Workers, "workers", 1, "詰み判定の探索に使うゴルーチンの数")
+	flag.BoolVar(&opts.Deterministic, "deterministic", false, "並列に探索しても毎回同じ解を表示する")
+	flag.IntVar(&opts.Memo, "memo", 0, "解のなかった部分局面を覚える表の大きさ（0 なら既定の大きさ、負なら覚えない）")
+	format := flag.String("format", "text", "出力形式 (text, json)")
+	var m mode
+	flag.BoolVar(&m.all, "all", false, "すべての解を表示する")
+	flag.BoolVar(&m.count, "count", false, "解の数だけを表示する")
+	flag.BoolVar(&m.minimal, "minimal", false, "場のメルドをできるだけ崩さない解を選ぶ")
+	flag.DurationVar(&m.timeout, "timeout", 0, "詰み判定の制限時間（0 なら無制限）。時間切れなら結果は不明になる")
+	flag.BoolVar(&m.stats, "stats", false, "探索の統計を表示する")
+	flag.Usage = func() {
+		fmt.Fprintln(flag.CommandLine.Output(), "Usage: rummikub-checkmate [flags] <json-file>")
+		fmt.Fprintln(flag.CommandLine.Output(), "       rummikub-checkmate [flags] solve [flags] <position | ->")
+		fmt.Fprintln(flag.CommandLine.Output(), "       rummikub-checkmate [flags] verify [flags] <position | -> <solution | ->")
+		fmt.Fprintln(flag.CommandLine.Output(), "       rummikub-checkmate [flags] check [flags] <position | -> <board | ->")
+		flag.PrintDefaults()
+	}
+
+	// サブコマンドは局面を表記で受け取る。フラグはサブコマンドの前にも後にも書ける
+	flag.CommandLine.Parse(os.Args[1:])
+	var command string
+	if arg := flag.Arg(0); arg == "solve" || arg == "verify" || arg == "check" {
+		command = arg
+		flag.CommandLine.Parse(flag.Args()[1:])
+	}
+
+	if flag.NArg() < 1 {
+		flag.Usage()
+		os.Exit(exitInputError)
+	}
+	if *format != "text" && *format != "json" {
+		fail(fmt.Errorf("unknown format: %s", *format), "text")
+	}
+
+	var err error
+	if opts.Engine, err = rummikub.ParseEngine(*engine); err != nil {
+		fail(err, *format)
+	}
+
+	if *minNumber < 1 {
+		fail(fmt.Errorf("invalid min number: %d", *minNumber), *format)
+	}
+	opts.Rules.MinNumber = rummikub.TileNumber(*minNumber)
+	opts.Rules.MaxNumber = rummikub.TileNumber(*maxNumber)
+	if opts.Rules.Colors, err = rummikub.ParseColors(*colors); err != nil {
+		fail(err, *format)
+	}
+	// Rules では 0 が既定値を表すので、フラグの 0（ジョーカーなし、点数を問わない）は負にする
+	opts.Rules.Jokers = noneIfZero(*jokers)
+	opts.Rules.InitialMeldPoints = noneIfZero(*initialPoints)
+	if err := opts.Rules.Validate(); err != nil {
+		fail(err, *format)
+	}
+
+	switch command {
+	case "verify":
+		os.Exit(runVerify(flag.Args(), opts.Rules, *format))
+	case "check":
+		os.Exit(runCheck(flag.Args(), opts.Rules, *format))
+	}
+
+	var gs *rummikub.GameState
+	if command == "solve" {
+		gs, err = readPosition(strings.Join(flag.Args(), " "), opts.Rules)
+	} else {
+		gs, err = rummikub.LoadGameStateWithRules(flag.Arg(0), opts.Rules)
+	}
+	if err != nil {
+		fail(err, *format)
+	}
+
+	if m.stats {
+		opts.Stats = &rummikub.Stats{}
+	}
+	var code int
+	if *format == "json" {
+		code = writeJSON(gs, opts, m)
+	} else {
+		code = printText(gs, opts, m)
+	}
+	os.Exit(code)
+}
+
+// noneIfZero は 0 を Rules の「なし」を表す負の値にする
+func noneIfZero(n int) int {
+	if n == 0 {
+		return -1
+	}
+	return n
+}
+
+// mode は何を求めてどう表示するかの指定
+type mode struct {
+	count, all, minimal bool
+	timeout             time.Duration
+	stats               bool
+}
+
+// printText は結果を表示し、終了コードを返す
+func printText(gs *rummikub.GameState, opts rummikub.Options, m mode) int {
+	fmt.Println(gs)
+	if opts.Stats != nil {
+		defer func() { fmt.Printf("\nStats: %v\n", *opts.Stats) }()
+	}
+
+	if m.count {
+		n, err := countSolutions(gs, opts, m.timeout)
+		if err != nil {
+			fmt.Printf("\nSolutions: ❓ %d 以上（%s）\n", n, unknownReason(err))
+			return partialExitCode(n)
+		}
+		fmt.Printf("\nSolutions: %d\n", n)
+		return exitCode(n > 0)
+	}
+	if m.all {
+		n, err := printAllSolutions(gs, opts, m.timeout)
+		if err != nil {
+			return partialExitCode(n)
+		}
+		return exitCode(n > 0)
+	}
+
+	// 詰み判定
+	fmt.Println("\nCheckmate Analysis:")
+	hasCheckmate, solution, infeasible, err := judge(gs, opts, m)
+	if err != nil {
+		fmt.Printf("  Result: ❓ 不明（%s）\n", unknownReason(err))
+		return exitUnknown
+	}
+	if hasCheckmate {
+		fmt.Println("  Result: ✅ 詰みあり（手札を出し切れる）")
+		fmt.Println("\n  Solution:")
+		melds := make([]rummikub.Meld, len(solution))
+		for i, m := range solution {
+			fmt.Printf("    %d: %s %s%s\n", i+1, m.Meld.String(), changeLabel(m), jokerLabel(m.Meld, opts.Rules))
+			melds[i] = m.Meld
+		}
+
+		fmt.Println("\n  Plan:")
+		for i, step := range rummikub.PlanMovesWithRules(gs.Board, gs.Hand, melds, opts.Rules) {
+			fmt.Printf("    %d. %s\n", i+1, step)
+		}
+	} else {
+		fmt.Printf("  Result: ❌ 詰みなし（%s）\n", noCheckmateReason(infeasible))
+		printMaxPlay(gs, opts, m.timeout)
+		printExplanation(gs, opts, m.timeout)
+	}
+	return exitCode(hasCheckmate)
+}
+
+// runVerify は局面と解を読み込んで解を確かめ、終了コードを返す。
+// 局面と解のどちらか一方は "-"（標準入力）にできる。
+func runVerify(args []string, r rummikub.Rules, format string) int {
+	if len(args) != 2 {
+		flag.Usage()
+		return exitInputError
+	}
+	gs, err := readPosition(args[0], r)
+	if err != nil {
+		fail(err, format)
+	}
+	solution, err := readMelds(args[1], r)
+	if err != nil {
+		fail(err, format)
+	}
+
+	err = rummikub.VerifyWithRules(gs, solution, r)
+	if format == "json" {
+		writeVerifyJSON(gs, solution, err)
+		return exitCode(err == nil)
+	}
+
+	fmt.Println(gs)
+	fmt.Println("\nSolution:")
+	for i, meld := range solution {
+		fmt.Printf("  %d: %s\n", i+1, meld.String())
+	}
+	fmt.Println("\nVerification:")
+	if err == nil {
+		fmt.Println("  Result: ✅ 正しい解")
+		return exitCheckmate
+	}
+	fmt.Println("  Result: ❌ 誤りあり")
+	for _, e := range errorList(err) {
+		fmt.Printf("    - %v\n", e)
+	}
+	return exitNoCheckmate
+}
+
+// runCheck は局面と組み替えた後の場を読み込んで手が正しいかを確かめ、終了コードを返す。
+// 局面と場のどちらか一方は "-"（標準入力）にできる。
+func runCheck(args []string, r rummikub.Rules, format string) int {
+	if len(args) != 2 {
+		flag.Usage()
+		return exitInputError
+	}
+	gs, err := readPosition(args[0], r)
+	if err != nil {
+		fail(err, format)
+	}
+	after, err := readMelds(args[1], r)
+	if err != nil {
+		fail(err, format)
+	}
+
+	played, err := rummikub.VerifyMoveWithRules(gs, after, r)
+	if format == "json" {
+		writeCheckJSON(gs, after, played, err)
+		return exitCode(err == nil)
+	}
+
+	fmt.Println(gs)
+	fmt.Println("\nProposed Board:")
+	for i, meld := range after {
+		fmt.Printf("  %d: %s\n", i+1, meld.String())
+	}
+	fmt.Println("\nMove Check:")
+	if err != nil {
+		fmt.Println("  Result: ❌ 出せない手")
+		for _, e := range errorList(err) {
+			fmt.Printf("    - %v\n", e)
+		}
+		return exitNoCheckmate
+	}
+	fmt.Println("  Result: ✅ 正しい手")
+	fmt.Printf("  Played: %s\n", rummikub.Meld(played).String())
+	if len(played) == len(gs.Hand.Tiles) {
+		fmt.Println("  手札を出し切った")
+	}
+	return exitCheckmate
+}
+
+// errorList は ValidationErrors を個々の誤りに分ける。それ以外の誤りは1つだけの並びにする
+func errorList(err error) []error {
+	var errs rummikub.ValidationErrors
+	if errors.As(err, &errs) {
+		return errs
+	}
+	return []error{err}
 }
 
-var tileMap = map[string]Tile{
-	"R1": R1, "R2": R2, "R3": R3, "R4": R4, "R5": R5, "R6": R6, "R7": R7,
-	"R8": R8, "R9": R9, "R10": R10, "R11": R11, "R12": R12, "R13": R13,
+// solveCheckmate は制限時間と Ctrl-C に従って詰み判定を行う。
+// Ctrl-C を受け取るのは詰み判定の間だけで、それ以外は通常どおり終了する。
+func solveCheckmate(gs *rummikub.GameState, opts rummikub.Options, timeout time.Duration) (bool, []rummikub.Meld, error) {
+	ctx, cancel := searchContext(timeout)
+	defer cancel()
+	return rummikub.SolveCheckmateContext(ctx, gs, opts)
+}
+
+// judge は m に従って詰み判定（-minimal なら場をできるだけ崩さない解の探索）を行う。
+// 探索の前の確認で詰みなしと分かったときは、その理由も返す
+func judge(gs *rummikub.GameState, opts rummikub.Options, m mode) (bool, []rummikub.SolutionMeld, *rummikub.InfeasibleError, error) {
+	// 理由は統計に書き込まれるので、-stats がなくても統計を受け取る
+	if opts.Stats == nil {
+		opts.Stats = &rummikub.Stats{}
+	}
+	if m.minimal {
+		ok, solution, err := solveMinimalDisruption(gs, opts, m.timeout)
+		return ok, solution, opts.Stats.Infeasible, err
+	}
+	ok, melds, err := solveCheckmate(gs, opts, m.timeout)
+	return ok, rummikub.ClassifySolution(gs.Board, melds), opts.Stats.Infeasible, err
+}
 
-	"B1": B1, "B2": B2, "B3": B3, "B4": B4, "B5": B5, "B6": B6, "B7": B7,
-	"B8": B8, "B9": B9, "B10": B10, "B11": B11, "B12": B12, "B13": B13,
+// solveMinimalDisruption は詰み判定と同じく制限時間と Ctrl-C に従って、場をできるだけ崩さない解を探す
+func solveMinimalDisruption(gs *rummikub.GameState, opts rummikub.Options, timeout time.Duration) (bool, []rummikub.SolutionMeld, error) {
+	ctx, cancel := searchContext(timeout)
+	defer cancel()
+	return rummikub.SolveCheckmateMinimalDisruptionContext(ctx, gs, opts)
+}
 
-	"Y1": Y1, "Y2": Y2, "Y3": Y3, "Y4": Y4, "Y5": Y5, "Y6": Y6, "Y7": Y7,
-	"Y8": Y8, "Y9": Y9, "Y10": Y10, "Y11": Y11, "Y12": Y12, "Y13": Y13,
+// countSolutions は詰み判定と同じく制限時間と Ctrl-C に従って解の数を数える。
+// 打ち切ったときは、それまでに見つけた解の数と誤りを返す
+func countSolutions(gs *rummikub.GameState, opts rummikub.Options, timeout time.Duration) (int, error) {
+	ctx, cancel := searchContext(timeout)
+	defer cancel()
+	return rummikub.CountSolutionsContext(ctx, gs, opts)
+}
 
-	"K1": K1, "K2": K2, "K3": K3, "K4": K4, "K5": K5, "K6": K6, "K7": K7,
-	"K8": K8, "K9": K9, "K10": K10, "K11": K11, "K12": K12, "K13": K13,
+// solveMaxPlay は詰み判定と同じく制限時間と Ctrl-C に従って、最も多く手札を出せる手を探す
+func solveMaxPlay(gs *rummikub.GameState, opts rummikub.Options, timeout time.Duration) (rummikub.MaxPlayResult, bool, error) {
+	ctx, cancel := searchContext(timeout)
+	defer cancel()
+	return rummikub.SolveMaxPlayContext(ctx, gs, opts)
+}
 
-	"JK": JK,
+// explainNoCheckmate は詰み判定と同じく制限時間と Ctrl-C に従って、詰みがない理由を調べる。
+// 打ち切ったときは false を返す。
+func explainNoCheckmate(gs *rummikub.GameState, opts rummikub.Options, timeout time.Duration) (rummikub.Explanation, bool) {
+	ctx, cancel := searchContext(timeout)
+	defer cancel()
+	ex, ok, err := rummikub.ExplainNoCheckmateContext(ctx, gs, opts)
+	return ex, ok && err == nil
 }
 
-func parseTile(s string) (Tile, error) {
-	s = strings.TrimSpace(s)
-	if t, ok := tileMap[s]; ok {
-		return t, nil
+// searchContext は制限時間（0 なら無制限）が切れるか Ctrl-C を受け取ると終わる context を返す
+func searchContext(timeout time.Duration) (context.Context, context.CancelFunc) {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	if timeout <= 0 {
+		return ctx, stop
 	}
-	return Tile{}, fmt.Errorf("invalid tile: %s", s)
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	return ctx, func() {
+		cancel()
+		stop()
+	}
+}
+
+// unknownReason は詰み判定を打ち切った理由を返す
+func unknownReason(err error) string {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return "時間切れ"
+	}
+	return "中断"
+}
+
+// partialExitCode は解の数え上げや列挙を打ち切ったときの終了コードを返す。
+// 解が1つでも見つかっていれば詰みはある
+func partialExitCode(found int) int {
+	if found > 0 {
+		return exitCheckmate
+	}
+	return exitUnknown
+}
+
+// exitCode は詰みの有無に応じた終了コードを返す
+func exitCode(checkmate bool) int {
+	if checkmate {
+		return exitCheckmate
+	}
+	return exitNoCheckmate
 }
 
-func LoadGameState(filename string) (*GameState, error) {
-	data, err := os.ReadFile(filename)
+// fail は入力の誤りを表示して終了する
+func fail(err error, format string) {
+	if format == "json" {
+		writeJSONError(err)
+		os.Exit(exitInputError)
+	}
+
+	for _, e := range errorList(err) {
+		fmt.Printf("Error: %v\n", e)
+	}
+	os.Exit(exitInputError)
+}
+
+// readPosition は引数（"-" なら標準入力）の表記から局面を読み込む
+func readPosition(arg string, r rummikub.Rules) (*rummikub.GameState, error) {
+	input, err := readArg(arg)
 	if err != nil {
 		return nil, err
 	}
+	gs, err := rummikub.ParsePositionWithRules(input, r)
+	return gs, withInput(err, input)
+}
 
-	var gsj GameStateJSON
-	if err := json.Unmarshal(data, &gsj); err != nil {
+// readMelds は引数（"-" なら標準入力）の表記からメルドの並びを読み込む
+func readMelds(arg string, r rummikub.Rules) ([]rummikub.Meld, error) {
+	input, err := readArg(arg)
+	if err != nil {
 		return nil, err
 	}
+	melds, err := rummikub.ParseMeldsWithRules(input, r)
+	return melds, withInput(err, input)
+}
+
+// readArg は引数をそのまま、"-" なら標準入力を読んで返す
+func readArg(arg string) (string, error) {
+	if arg != "-" {
+		return arg, nil
+	}
+	data, err := io.ReadAll(os.Stdin)
+	return string(data), err
+}
 
-	gs := &GameState{}
+// withInput は表記の誤りに入力を添えて positionError にする
+func withInput(err error, input string) error {
+	var nerr *rummikub.NotationError
+	if errors.As(err, &nerr) {
+		return &positionError{NotationError: nerr, input: input}
+	}
+	return err
+}
 
-	// Board変換
-	for _, meldStrings := range gsj.Board {
-		var tiles []Tile
-		for _, s := range meldStrings {
-			tile, err := parseTile(s)
-			if err != nil {
-				return nil, err
-			}
-			tiles = append(tiles, tile)
-		}
-		gs.Board.Melds = append(gs.Board.Melds, Meld(tiles))
+// positionError は表記の誤りを、入力の該当行と位置を示して表示する
+type positionError struct {
+	*rummikub.NotationError
+	input string
+}
+
+func (e *positionError) Error() string {
+	line := strings.Split(e.input, "\n")[e.Line-1]
+	return fmt.Sprintf("%v\n  %s\n  %s^", e.NotationError, line, strings.Repeat(" ", e.Column-1))
+}
+
+func (e *positionError) Unwrap() error {
+	return e.NotationError
+}
+
+// changeLabel は解のメルドが元の場からどう変わったかの表示を返す
+func changeLabel(m rummikub.SolutionMeld) string {
+	switch m.Change {
+	case rummikub.MeldUnchanged:
+		return fmt.Sprintf("（そのまま: 場の%d）", m.Origin+1)
+	case rummikub.MeldExtended:
+		return fmt.Sprintf("（付け足し: 場の%d）", m.Origin+1)
+	default:
+		return "（新規）"
+	}
+}
+
+// jokerLabel はメルドのジョーカーが代わりをしているタイルの表示（" JK=B8" など）を返す
+func jokerLabel(meld rummikub.Meld, r rummikub.Rules) string {
+	res, err := meld.ResolveWith(r, false)
+	if err != nil {
+		return ""
 	}
+	var label string
+	for _, tile := range res.Jokers() {
+		label += " JK=" + tile.Notation()
+	}
+	return label
+}
 
-	// Hand変換
-	for _, s := range gsj.Hand {
-		tile, err := parseTile(s)
+// printAllSolutions は詰み判定と同じく制限時間と Ctrl-C に従ってすべての解を表示し、解の数を返す。
+// 打ち切ったときは、それまでに表示した解の数と誤りを返す
+func printAllSolutions(gs *rummikub.GameState, opts rummikub.Options, timeout time.Duration) (int, error) {
+	ctx, cancel := searchContext(timeout)
+	defer cancel()
+	n := 0
+	for solution, err := range rummikub.SolutionsContext(ctx, gs, opts) {
 		if err != nil {
-			return nil, err
+			fmt.Printf("\nSolutions: ❓ %d 以上（%s）\n", n, unknownReason(err))
+			return n, err
+		}
+		n++
+		fmt.Printf("\nSolution %d:\n", n)
+		for i, meld := range solution {
+			fmt.Printf("    %d: %s%s\n", i+1, meld.String(), jokerLabel(meld, opts.Rules))
 		}
-		gs.Hand.Tiles = append(gs.Hand.Tiles, tile)
 	}
+	fmt.Printf("\nSolutions: %d\n", n)
+	return n, nil
+}
 
-	return gs, nil
+// noCheckmateReason は詰みがない理由を返す。探索の前の確認で分かった理由 infeasible があればそれを示す
+func noCheckmateReason(infeasible *rummikub.InfeasibleError) string {
+	if infeasible == nil {
+		return "手札を出し切れない"
+	}
+	tiles := strings.Join(notations(infeasible.Tiles), " ")
+	if infeasible.Check == "jokers" {
+		return fmt.Sprintf("%s を別々のメルドに置くにはジョーカーが %d 枚要るが、%d 枚しかない", tiles, infeasible.Need, infeasible.Have)
+	}
+	return fmt.Sprintf("%s を含むメルドを作れない", tiles)
 }
 
-func main() {
-	if len(os.Args) < 2 {
-		fmt.Println("Usage: rummikub-checkmate <json-file>")
-		os.Exit(1)
+// printExplanation は詰みがないときに、手札に残すべきタイルと一緒には出せないタイルを表示する
+func printExplanation(gs *rummikub.GameState, opts rummikub.Options, timeout time.Duration) {
+	ex, ok := explainNoCheckmate(gs, opts, timeout)
+	if !ok {
+		return
 	}
+	fmt.Println("\n  Explanation:")
+	fmt.Printf("    Hold: %s（手札に残せば、残りの手札を出し切れる最少の組）\n", tileList(ex.Hold))
+	fmt.Printf("    Core: %s（一緒には出し切れない組。どれか1枚を残せば残りは出し切れる）\n", tileList(ex.Core))
+}
 
-	gs, err := LoadGameState(os.Args[1])
-	if err != nil {
-		fmt.Printf("Error: %v\n", err)
-		os.Exit(1)
+// tileList は色付きのタイルを空白で区切って並べる
+func tileList(tiles []rummikub.Tile) string {
+	names := make([]string, len(tiles))
+	for i, tile := range tiles {
+		names[i] = tile.String()
 	}
+	return strings.Join(names, " ")
+}
 
-	fmt.Println(gs)
+// printMaxPlay は詰みがないときに、最も多く手札を出せる手を表示する
+func printMaxPlay(gs *rummikub.GameState, opts rummikub.Options, timeout time.Duration) {
+	result, ok, err := solveMaxPlay(gs, opts, timeout)
+	if err != nil {
+		fmt.Printf("\n  Max Play: ❓ 不明（%s）\n", unknownReason(err))
+		return
+	}
+	if !ok {
+		fmt.Println("\n  場のタイルだけで有効なメルドを組めません")
+		return
+	}
+	if result.Played == 0 {
+		fmt.Println("\n  出せる手札はありません")
+		return
+	}
 
-	// 詰み判定
-	fmt.Println("\nCheckmate Analysis:")
-	hasCheckmate, solution := SolveCheckmate(gs.Board, gs.Hand)
-	if hasCheckmate {
-		fmt.Println("  Result: ✅ 詰みあり（手札を出し切れる）")
-		fmt.Println("\n  Solution:")
-		for i, meld := range solution {
-			fmt.Printf("    %d: %s\n", i+1, meld.String())
-		}
-	} else {
-		fmt.Println("  Result: ❌ 詰みなし（手札を出し切れない）")
+	fmt.Printf("\n  Max Play: %d枚出せる\n", result.Played)
+	for i, meld := range result.Board.Melds {
+		fmt.Printf("    %d: %s%s\n", i+1, meld.String(), jokerLabel(meld, opts.Rules))
 	}
+	fmt.Printf("  Remaining %s\n", result.Hand.String())
 }
@@ -0,0 +1,271 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"time"
+
+	"rummikub-checkmate/rummikub"
+)
+
+// resultJSON は -format json の出力
+type resultJSON struct {
+	Position  rummikub.GameStateJSON `json:"position"`
+	Checkmate bool                   `json:"checkmate"`
+	Solution  []meldJSON             `json:"solution,omitempty"`
+	MaxPlay   *maxPlayJSON           `json:"max_play,omitempty"`
+	Cause     *causeJSON             `json:"cause,omitempty"` // 探索の前の確認で詰みなしと分かった理由
+	Explain   *explainJSON           `json:"explanation,omitempty"`
+	Count     *int                   `json:"count,omitempty"`
+	Solutions [][]meldJSON           `json:"solutions,omitempty"`
+	Unknown   bool                   `json:"unknown,omitempty"` // 時間切れや中断で探索を打ち切った
+	Reason    string                 `json:"reason,omitempty"`  // 分からない理由（"timeout", "interrupted"）
+	Stats     *statsJSON             `json:"stats,omitempty"`
+}
+
+// statsJSON は -stats を付けたときの探索の統計
+type statsJSON struct {
+	Candidates int     `json:"candidates"`
+	Nodes      int     `json:"nodes"`
+	Backtracks int     `json:"backtracks"`
+	MemoProbes int     `json:"memo_probes"`
+	MemoHits   int     `json:"memo_hits"`
+	ElapsedMS  float64 `json:"elapsed_ms"`
+}
+
+// causeJSON は探索の前の確認で詰みなしと分かった理由
+type causeJSON struct {
+	Check string   `json:"check"` // "no-meld" または "jokers"
+	Tiles []string `json:"tiles"`
+	Need  int      `json:"need,omitempty"` // "jokers" のとき、要るジョーカーの枚数
+	Have  int      `json:"have,omitempty"` // "jokers" のとき、使えるジョーカーの枚数
+}
+
+// explainJSON は詰みがないときに手札を出し切るのを妨げているタイル
+type explainJSON struct {
+	Hold []string `json:"hold"` // 手札に残せば残りを出し切れる最少の組
+	Core []string `json:"core"` // 一緒には出し切れない組
+}
+
+// meldJSON は解のメルド。タイルは GameStateJSON と同じ表記で表す
+type meldJSON struct {
+	Tiles  []string `json:"tiles"`
+	Kind   string   `json:"kind"`
+	Jokers []string `json:"jokers,omitempty"` // ジョーカーが代わりをしているタイル（並び順）
+	Change string   `json:"change,omitempty"`
+	Origin int      `json:"origin,omitempty"` // 元の場のメルドの番号（1始まり）
+}
+
+// maxPlayJSON は詰みがないときに最も多く手札を出せる手
+type maxPlayJSON struct {
+	Played int        `json:"played"`
+	Melds  []meldJSON `json:"melds"`
+	Hand   []string   `json:"hand"`
+}
+
+// writeJSON は結果をJSONで出力し、終了コードを返す
+func writeJSON(gs *rummikub.GameState, opts rummikub.Options, m mode) int {
+	result := resultJSON{Position: gs.ToJSON()}
+	code := writeResult(&result, gs, opts, m)
+	if opts.Stats != nil {
+		s := *opts.Stats
+		result.Stats = &statsJSON{
+			Candidates: s.Candidates,
+			Nodes:      s.Nodes,
+			Backtracks: s.Backtracks,
+			MemoProbes: s.MemoProbes,
+			MemoHits:   s.MemoHits,
+			ElapsedMS:  float64(s.Elapsed.Microseconds()) / 1000,
+		}
+	}
+	encode(result)
+	return code
+}
+
+// writeResult は結果を result に書き込み、終了コードを返す
+func writeResult(result *resultJSON, gs *rummikub.GameState, opts rummikub.Options, m mode) int {
+	switch {
+	case m.count:
+		n, err := countSolutions(gs, opts, m.timeout)
+		result.Count = &n
+		result.Checkmate = n > 0
+		if err != nil {
+			setUnknown(result, err)
+			return partialExitCode(n)
+		}
+	case m.all:
+		ctx, cancel := searchContext(m.timeout)
+		defer cancel()
+		for solution, err := range rummikub.SolutionsContext(ctx, gs, opts) {
+			if err != nil {
+				result.Checkmate = len(result.Solutions) > 0
+				setUnknown(result, err)
+				return partialExitCode(len(result.Solutions))
+			}
+			var melds []meldJSON
+			for _, m := range rummikub.ClassifySolution(gs.Board, solution) {
+				melds = append(melds, newMeldJSON(m, opts.Rules))
+			}
+			result.Solutions = append(result.Solutions, melds)
+		}
+		result.Checkmate = len(result.Solutions) > 0
+	default:
+		var solution []rummikub.SolutionMeld
+		var infeasible *rummikub.InfeasibleError
+		var err error
+		result.Checkmate, solution, infeasible, err = judge(gs, opts, m)
+		if err != nil {
+			setUnknown(result, err)
+			return exitUnknown
+		}
+		for _, m := range solution {
+			result.Solution = append(result.Solution, newMeldJSON(m, opts.Rules))
+		}
+		if !result.Checkmate {
+			result.MaxPlay = newMaxPlayJSON(gs, opts, m.timeout)
+			if ex, ok := explainNoCheckmate(gs, opts, m.timeout); ok {
+				result.Explain = &explainJSON{Hold: notations(ex.Hold), Core: notations(ex.Core)}
+			}
+			if infeasible != nil {
+				result.Cause = &causeJSON{
+					Check: infeasible.Check,
+					Tiles: notations(infeasible.Tiles),
+					Need:  infeasible.Need,
+					Have:  infeasible.Have,
+				}
+			}
+		}
+	}
+
+	return exitCode(result.Checkmate)
+}
+
+// setUnknown は探索を打ち切った理由を result に書き込む
+func setUnknown(result *resultJSON, err error) {
+	result.Unknown = true
+	result.Reason = "interrupted"
+	if errors.Is(err, context.DeadlineExceeded) {
+		result.Reason = "timeout"
+	}
+}
+
+// verifyJSON は verify サブコマンドの -format json の出力
+type verifyJSON struct {
+	Position rummikub.GameStateJSON `json:"position"`
+	Solution [][]string             `json:"solution"`
+	Valid    bool                   `json:"valid"`
+	Errors   []string               `json:"errors,omitempty"`
+}
+
+// writeVerifyJSON は解を確かめた結果をJSONで出力する
+func writeVerifyJSON(gs *rummikub.GameState, solution []rummikub.Meld, err error) {
+	result := verifyJSON{Position: gs.ToJSON(), Solution: [][]string{}, Valid: err == nil}
+	for _, meld := range solution {
+		result.Solution = append(result.Solution, notations(meld))
+	}
+	if err != nil {
+		result.Errors = errorStrings(err)
+	}
+	encode(result)
+}
+
+// checkJSON は check サブコマンドの -format json の出力
+type checkJSON struct {
+	Position rummikub.GameStateJSON `json:"position"`
+	Board    [][]string             `json:"board"`
+	Legal    bool                   `json:"legal"`
+	Played   []string               `json:"played"`
+	Errors   []string               `json:"errors,omitempty"`
+}
+
+// writeCheckJSON は手を確かめた結果をJSONで出力する
+func writeCheckJSON(gs *rummikub.GameState, after []rummikub.Meld, played []rummikub.Tile, err error) {
+	result := checkJSON{Position: gs.ToJSON(), Board: [][]string{}, Legal: err == nil, Played: notations(played)}
+	for _, meld := range after {
+		result.Board = append(result.Board, notations(meld))
+	}
+	if err != nil {
+		result.Errors = errorStrings(err)
+	}
+	encode(result)
+}
+
+// errorStrings は誤りを1つずつ文字列にする
+func errorStrings(err error) []string {
+	var result []string
+	for _, e := range errorList(err) {
+		result = append(result, e.Error())
+	}
+	return result
+}
+
+// errorJSON は入力の誤り。表記の誤りなら位置（1始まり）も示し、
+// 誤りが複数あれば errors に1つずつ入れる
+type errorJSON struct {
+	Error  string   `json:"error"`
+	Line   int      `json:"line,omitempty"`
+	Column int      `json:"column,omitempty"`
+	Errors []string `json:"errors,omitempty"`
+}
+
+// writeJSONError は入力の誤りをJSONで出力する
+func writeJSONError(err error) {
+	result := errorJSON{Error: err.Error()}
+	var nerr *rummikub.NotationError
+	var errs rummikub.ValidationErrors
+	switch {
+	case errors.As(err, &nerr):
+		result = errorJSON{Error: nerr.Error(), Line: nerr.Line, Column: nerr.Column}
+	case errors.As(err, &errs):
+		for _, e := range errs {
+			result.Errors = append(result.Errors, e.Error())
+		}
+	}
+	encode(result)
+}
+
+func encode(v any) {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	enc.Encode(v)
+}
+
+func newMeldJSON(m rummikub.SolutionMeld, r rummikub.Rules) meldJSON {
+	result := meldJSON{
+		Tiles:  notations(m.Meld),
+		Kind:   m.Meld.KindWith(r).String(),
+		Jokers: notations(m.Meld.JokerTilesWith(r)),
+		Change: m.Change.String(),
+	}
+	if m.Change != rummikub.MeldNew {
+		result.Origin = m.Origin + 1
+	}
+	return result
+}
+
+func newMaxPlayJSON(gs *rummikub.GameState, opts rummikub.Options, timeout time.Duration) *maxPlayJSON {
+	played, ok, err := solveMaxPlay(gs, opts, timeout)
+	if err != nil || !ok {
+		return nil
+	}
+	result := &maxPlayJSON{Played: played.Played, Hand: notations(played.Hand.Tiles)}
+	for _, meld := range played.Board.Melds {
+		result.Melds = append(result.Melds, meldJSON{
+			Tiles:  notations(meld),
+			Kind:   meld.KindWith(opts.Rules).String(),
+			Jokers: notations(meld.JokerTilesWith(opts.Rules)),
+		})
+	}
+	return result
+}
+
+// notations はタイルを表記の並びにする
+func notations(tiles []rummikub.Tile) []string {
+	result := make([]string, len(tiles))
+	for i, tile := range tiles {
+		result[i] = tile.Notation()
+	}
+	return result
+}
@@ -0,0 +1,104 @@
+package rummikub
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"slices"
+	"testing"
+)
+
+// idKey はタイルIDの集合のキーを返す
+func idKey(tiles []Tile) string {
+	ids := make([]int, len(tiles))
+	for i, tile := range tiles {
+		ids[i] = int(tile.ID)
+	}
+	slices.Sort(ids)
+	return fmt.Sprint(ids)
+}
+
+// checkCandidates は tiles のすべての部分集合のうち有効なメルドになるものと、
+// GenerateAllCandidatesWithRules が生成する候補が一致するかを確認する
+func checkCandidates(t *testing.T, tiles []Tile, r Rules) {
+	t.Helper()
+
+	generated := make(map[string]bool)
+	for _, candidate := range GenerateAllCandidatesWithRules(tiles, r) {
+		key := idKey(candidate)
+		if generated[key] {
+			t.Errorf("%s: duplicate candidate %s", Meld(tiles), Meld(candidate))
+		}
+		generated[key] = true
+	}
+
+	for mask := 1; mask < 1<<len(tiles); mask++ {
+		var subset Meld
+		for i, tile := range tiles {
+			if mask&(1<<i) != 0 {
+				subset = append(subset, tile)
+			}
+		}
+		key := idKey(subset)
+		if valid := subset.IsValidWith(r); valid != generated[key] {
+			t.Errorf("%s: subset %s valid = %v, generated = %v", Meld(tiles), subset, valid, generated[key])
+		}
+		delete(generated, key)
+	}
+	for key := range generated {
+		t.Errorf("%s: generated candidate %s is not a subset", Meld(tiles), key)
+	}
+}
+
+func TestGenerateAllCandidates_BruteForce(t *testing.T) {
+	tests := []struct {
+		name  string
+		tiles []Tile
+	}{
+		{"three colors and joker", []Tile{R5, B5, Y5, JK}},
+		{"two jokers", []Tile{R5, B5, Y5, K5, JK, JK}},
+		{"run replaced by jokers", []Tile{R3, R4, R5, R6, JK, JK}},
+		{"duplicates", []Tile{R3, R4, R4, R5, B4, B4, JK}},
+		{"mostly jokers", []Tile{R1, B13, JK, JK}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checkCandidates(t, collectTiles(Board{}, Hand{Tiles: tt.tiles}), DefaultRules())
+		})
+	}
+
+	// 小さなデッキから乱数で選んだタイルで比較する
+	var deck []Tile
+	for _, color := range []Color{Red, Blue, Yellow} {
+		for n := TileNumber(1); n <= 5; n++ {
+			deck = append(deck, NewTile(color, n), NewTile(color, n))
+		}
+	}
+	deck = append(deck, JK, JK)
+
+	rng := rand.New(rand.NewPCG(5, 6))
+	for i := 0; i < 100; i++ {
+		rng.Shuffle(len(deck), func(a, b int) { deck[a], deck[b] = deck[b], deck[a] })
+		checkCandidates(t, collectTiles(Board{}, Hand{Tiles: slices.Clone(deck[:10])}), DefaultRules())
+	}
+}
+
+func TestGenerateAllCandidatesWithRules_BruteForce(t *testing.T) {
+	r := Rules{
+		MinNumber:   1,
+		MaxNumber:   6,
+		Colors:      []Color{Red, Blue, Yellow},
+		Copies:      2,
+		Jokers:      3,
+		MinMeldSize: 2,
+		WrapRuns:    true,
+	}
+	tests := [][]Tile{
+		{R5, R6, R1, JK},
+		{R1, R2, R3, R4, R5, R6, JK},
+		{JK, JK, JK, B2},
+		{R2, B2, Y2, R2, JK},
+	}
+	for _, tiles := range tests {
+		checkCandidates(t, collectTiles(Board{}, Hand{Tiles: tiles}), r)
+	}
+}
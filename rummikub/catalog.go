@@ -0,0 +1,242 @@
+package rummikub
+
+import (
+	"fmt"
+	"slices"
+	"sync"
+)
+
+// catalog はルールで作れるメルドの形をすべて並べた表。ルールごとに一度だけ作る（catalogFor）。
+// タイルの種類（色と数字、ジョーカー）に番号を付け、各メルドを種類ごとの枚数で表す。
+// 候補の生成は、手元のタイルの枚数で作れるメルドを表から選ぶだけになる。
+type catalog struct {
+	rules  Rules
+	joker  int           // ジョーカーの種類の番号（通常のタイルの種類の数）
+	melds  []catalogMeld // ラン、グループ、ジョーカーだけのメルドの順
+	byTile [][]int       // 種類ごとに、その種類を含むメルドの番号（昇順）
+}
+
+// catalogMeld はカタログの1つのメルド
+type catalogMeld struct {
+	size   int
+	first  int         // 含む種類のうち最小の番号
+	counts []kindCount // 種類ごとの枚数（メルド内で最初に現れる順）
+}
+
+// kindCount はメルド内の1つの種類と、その種類を置く位置
+type kindCount struct {
+	kind int
+	pos  []int
+}
+
+var catalogs sync.Map // catalogKey → *catalog
+
+// catalogFor はルール r のカタログを返す。r は withDefaults 済みであること
+func catalogFor(r Rules) *catalog {
+	key := catalogKey(r)
+	if c, ok := catalogs.Load(key); ok {
+		return c.(*catalog)
+	}
+	c, _ := catalogs.LoadOrStore(key, buildCatalog(r))
+	return c.(*catalog)
+}
+
+// catalogKey はメルドの形に関わるルールの項目だけからキーを作る
+func catalogKey(r Rules) string {
+	return fmt.Sprint(r.MinNumber, r.MaxNumber, r.Colors, r.jokerCount(), r.MinMeldSize, r.WrapRuns)
+}
+
+// kind はタイルの種類の番号を返す。ルールのデッキにないタイルなら false
+func (c *catalog) kind(t Tile) (int, bool) {
+	if !c.rules.validTile(t) {
+		return 0, false
+	}
+	if t.IsJoker {
+		return c.joker, true
+	}
+	return slices.Index(c.rules.Colors, t.Color)*c.rules.numberCount() + int(t.Number-c.rules.MinNumber), true
+}
+
+// buildCatalog はルール r で作れるメルドの形をすべて作る。
+// ランは各色の連続する数字の範囲ごとに、少なくとも1枚を残してジョーカーに置き換える位置をすべて選ぶ。
+// グループは各数字の色の部分集合にジョーカーを足す。最後にジョーカーだけのメルドを加える。
+// 端のジョーカーの位置だけが違うランなど、同じタイルの組み合わせになる形は最初の1つだけを残す。
+func buildCatalog(r Rules) *catalog {
+	count := r.numberCount()
+	c := &catalog{rules: r, joker: len(r.Colors) * count}
+	c.byTile = make([][]int, c.joker+1)
+
+	seen := make(map[string]bool)
+	add := func(shape []Tile) {
+		key := meldKey(shape)
+		if seen[key] {
+			return
+		}
+		seen[key] = true
+
+		m := catalogMeld{size: len(shape), first: c.joker}
+		for i, tile := range shape {
+			k, _ := c.kind(tile)
+			j := slices.IndexFunc(m.counts, func(kc kindCount) bool { return kc.kind == k })
+			if j < 0 {
+				j = len(m.counts)
+				m.counts = append(m.counts, kindCount{kind: k})
+			}
+			m.counts[j].pos = append(m.counts[j].pos, i)
+			m.first = min(m.first, k)
+		}
+		id := len(c.melds)
+		c.melds = append(c.melds, m)
+		for _, kc := range m.counts {
+			c.byTile[kc.kind] = append(c.byTile[kc.kind], id)
+		}
+	}
+
+	// ラン
+	for _, color := range r.Colors {
+		for length := r.MinMeldSize; length <= count; length++ {
+			last := r.MaxNumber - TileNumber(length) + 1
+			if r.WrapRuns && length < count {
+				last = r.MaxNumber
+			}
+			all := make([]int, length)
+			for k := range all {
+				all[k] = k
+			}
+
+			for start := r.MinNumber; start <= last; start++ {
+				for jokers := 0; jokers < length && jokers <= r.jokerCount(); jokers++ {
+					for _, replaced := range combinations(all, jokers) {
+						run := make([]Tile, length)
+						for k := range run {
+							run[k] = NewTile(color, r.runNumber(start, k))
+							if slices.Contains(replaced, k) {
+								run[k] = JK
+							}
+						}
+						add(run)
+					}
+				}
+			}
+		}
+	}
+
+	// グループ（色の番号順）
+	colors := slices.Clone(r.Colors)
+	slices.Sort(colors)
+	colors = slices.Compact(colors)
+	for num := r.MinNumber; num <= r.MaxNumber; num++ {
+		tiles := make([]Tile, len(colors))
+		for i, color := range colors {
+			tiles[i] = NewTile(color, num)
+		}
+		for size := 1; size <= len(tiles); size++ {
+			for _, combo := range getCombinations(tiles, size) {
+				for jokers := 0; jokers <= r.jokerCount(); jokers++ {
+					if size+jokers < r.MinMeldSize || size+jokers > len(colors) {
+						continue
+					}
+					add(append(combo, slices.Repeat([]Tile{JK}, jokers)...))
+				}
+			}
+		}
+	}
+
+	// ジョーカーだけのメルドは、ランかグループとして並べられる枚数なら有効とみなす
+	for size := r.MinMeldSize; size <= r.jokerCount(); size++ {
+		if size <= count || size <= r.colorCount() {
+			add(slices.Repeat([]Tile{JK}, size))
+		}
+	}
+	return c
+}
+
+// candidates は tiles で作れるメルドを、どの物理的なコピーを使うかの組み合わせごとにすべて返す。
+// 順序はカタログの順で、同じ形の中ではコピーの組み合わせの順になる。
+func (c *catalog) candidates(tiles []Tile) [][]Tile {
+	copies := make([][]Tile, len(c.byTile))
+	for _, tile := range tiles {
+		if k, ok := c.kind(tile); ok {
+			copies[k] = append(copies[k], tile)
+		}
+	}
+
+	// 各メルドは含む種類のうち最小の番号のところでだけ確かめる
+	var ids []int
+	for k, list := range c.byTile {
+		if len(copies[k]) == 0 {
+			continue
+		}
+		for _, id := range list {
+			if m := &c.melds[id]; m.first == k && m.fits(copies) {
+				ids = append(ids, id)
+			}
+		}
+	}
+	slices.Sort(ids)
+
+	var result [][]Tile
+	for _, id := range ids {
+		result = c.melds[id].expand(copies, result)
+	}
+	return result
+}
+
+// fits は種類ごとのコピー copies でメルドを作れるかを返す
+func (m *catalogMeld) fits(copies [][]Tile) bool {
+	for _, kc := range m.counts {
+		if len(copies[kc.kind]) < len(kc.pos) {
+			return false
+		}
+	}
+	return true
+}
+
+// expand はメルドの各種類の位置に、その種類のコピーから選んだタイルを置いたものをすべて result に加える。
+// 同じ種類を k 枚使うなら（ジョーカー）、コピーから k 枚を選ぶ組み合わせを使う。
+// 組み合わせの数を先に数え、タイルはまとめて確保する。
+func (m *catalogMeld) expand(copies [][]Tile, result [][]Tile) [][]Tile {
+	total := 1
+	for _, kc := range m.counts {
+		total *= binomial(len(copies[kc.kind]), len(kc.pos))
+	}
+	backing := make([]Tile, total*m.size)
+	buf := make([]Tile, m.size)
+
+	// 前の種類ほど速く変わる順に並べる
+	var fill func(i int)
+	var choose func(i, from, k int)
+	fill = func(i int) {
+		if i < 0 {
+			meld := backing[:m.size:m.size]
+			backing = backing[m.size:]
+			copy(meld, buf)
+			result = append(result, meld)
+			return
+		}
+		choose(i, 0, 0)
+	}
+	choose = func(i, from, k int) {
+		kc := m.counts[i]
+		if k == len(kc.pos) {
+			fill(i - 1)
+			return
+		}
+		tiles := copies[kc.kind]
+		for x := from; x <= len(tiles)-(len(kc.pos)-k); x++ {
+			buf[kc.pos[k]] = tiles[x]
+			choose(i, x+1, k+1)
+		}
+	}
+	fill(len(m.counts) - 1)
+	return result
+}
+
+// binomial は n 個から k 個を選ぶ組み合わせの数を返す
+func binomial(n, k int) int {
+	result := 1
+	for i := 0; i < k; i++ {
+		result = result * (n - i) / (i + 1)
+	}
+	return result
+}
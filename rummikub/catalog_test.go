@@ -0,0 +1,75 @@
+package rummikub
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+)
+
+func TestCatalog(t *testing.T) {
+	for _, r := range []Rules{
+		DefaultRules(),
+		{MinNumber: 1, MaxNumber: 6, Colors: []Color{Red, Blue, Yellow}, Copies: 2, Jokers: 3, MinMeldSize: 2, WrapRuns: true},
+	} {
+		r = r.withDefaults()
+		c := catalogFor(r)
+		if catalogFor(r) != c {
+			t.Errorf("%v: catalogFor() built the catalog twice", r)
+		}
+
+		// 各メルドの種類の枚数から形を組み立て直すと、重複のない有効なメルドになる
+		tiles := make([]Tile, c.joker+1)
+		for _, tile := range collectTiles(Board{}, Hand{Tiles: fullDeck(r)}) {
+			if k, ok := c.kind(tile); ok {
+				tiles[k] = tile
+			}
+		}
+		seen := make(map[string]bool)
+		for id, m := range c.melds {
+			meld := make(Meld, m.size)
+			for _, kc := range m.counts {
+				for _, p := range kc.pos {
+					meld[p] = tiles[kc.kind]
+				}
+				if !slices.Contains(c.byTile[kc.kind], id) {
+					t.Errorf("%v: %s is not indexed by %s", r, meld, tiles[kc.kind])
+				}
+			}
+			if !meld.IsValidWith(r) {
+				t.Errorf("%v: invalid meld %s", r, meld)
+			}
+			key := meldKey(meld)
+			if seen[key] {
+				t.Errorf("%v: duplicate meld %s", r, meld)
+			}
+			seen[key] = true
+		}
+	}
+}
+
+// fullDeck はルール r のデッキのタイルを種類ごとに1枚ずつ返す
+func fullDeck(r Rules) []Tile {
+	var deck []Tile
+	for _, color := range r.Colors {
+		for n := r.MinNumber; n <= r.MaxNumber; n++ {
+			deck = append(deck, NewTile(color, n))
+		}
+	}
+	if r.Jokers > 0 {
+		deck = append(deck, JK)
+	}
+	return deck
+}
+
+func BenchmarkGenerateAllCandidates(b *testing.B) {
+	for _, size := range []int{20, 40, 60} {
+		board, hand := generatePositionWithCopies(uint64(size), size, 2, 2)
+		tiles := collectTiles(board, hand)
+		b.Run(fmt.Sprintf("Generated%d", size), func(b *testing.B) {
+			b.ReportAllocs()
+			for b.Loop() {
+				GenerateAllCandidatesWithRules(tiles, DefaultRules())
+			}
+		})
+	}
+}
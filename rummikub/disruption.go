@@ -0,0 +1,163 @@
+package rummikub
+
+import "context"
+
+// MeldChange は解のメルドが元の場のメルドからどう変わったかを表す
+type MeldChange int
+
+const (
+	// MeldNew は新しく作られたメルド
+	MeldNew MeldChange = iota
+	// MeldUnchanged は元の場のメルドそのまま
+	MeldUnchanged
+	// MeldExtended は元の場のメルドにタイルを付け足したもの
+	MeldExtended
+)
+
+func (c MeldChange) String() string {
+	switch c {
+	case MeldNew:
+		return "new"
+	case MeldUnchanged:
+		return "unchanged"
+	case MeldExtended:
+		return "extended"
+	default:
+		return "unknown"
+	}
+}
+
+// SolutionMeld は解のメルドと、元の場との関係
+type SolutionMeld struct {
+	Meld   Meld
+	Change MeldChange
+	Origin int // 元にした場のメルドの番号（0始まり）。新しいメルドは -1
+}
+
+// Disruption は解が元の場をどれだけ崩したか
+type Disruption struct {
+	Intact int // 崩さずに残した（付け足しを含む）元のメルドの数
+	Moved  int // 元のメルドから別のメルドへ移った場のタイルの数
+}
+
+// better は d が other より場を崩していないかを返す
+func (d Disruption) better(other Disruption) bool {
+	if d.Intact != other.Intact {
+		return d.Intact > other.Intact
+	}
+	return d.Moved < other.Moved
+}
+
+// SolveCheckmateMinimalDisruption は詰みの解のうち、元の場のメルドを
+// できるだけ多く残し、次に動かす場のタイルが最も少ないものを返す
+func SolveCheckmateMinimalDisruption(board Board, hand Hand) (bool, []SolutionMeld) {
+	gs := &GameState{Board: board, Hand: hand, Opened: true}
+	return SolveCheckmateMinimalDisruptionWithOptions(gs, DefaultOptions())
+}
+
+// SolveCheckmateMinimalDisruptionWithOptions はゲームの状態と探索設定を指定して
+// SolveCheckmateMinimalDisruption を行う
+func SolveCheckmateMinimalDisruptionWithOptions(gs *GameState, opts Options) (bool, []SolutionMeld) {
+	ok, solution, _ := SolveCheckmateMinimalDisruptionContext(context.Background(), gs, opts)
+	return ok, solution
+}
+
+// SolveCheckmateMinimalDisruptionContext は ctx の期限とキャンセルに従って
+// SolveCheckmateMinimalDisruptionWithOptions を行う。打ち切ったときは ctx.Err() を返す
+func SolveCheckmateMinimalDisruptionContext(ctx context.Context, gs *GameState, opts Options) (bool, []SolutionMeld, error) {
+	perfect := Disruption{Intact: len(gs.Board.Melds)}
+
+	var best []Meld
+	var bestScore Disruption
+	found := false
+	_, _, err := findCheckmateContext(ctx, gs, opts, func(solution [][]Tile) bool {
+		score := MeasureDisruption(gs.Board, toMelds(solution))
+		if !found || score.better(bestScore) {
+			found = true
+			best = toMelds(arrangeSolution(solution, gs.Board, opts.Rules.withDefaults()))
+			bestScore = score
+		}
+		// 1枚も動かさない解が見つかればそれ以上は探さない
+		return score == perfect
+	})
+	if err != nil {
+		return false, nil, err
+	}
+	if !found {
+		return false, nil, nil
+	}
+	return true, ClassifySolution(gs.Board, best), nil
+}
+
+// meldOf は解の各タイルIDが何番目のメルドに入っているかを返す
+func meldOf(solution []Meld) map[TileID]int {
+	index := make(map[TileID]int)
+	for i, meld := range solution {
+		for _, tile := range meld {
+			index[tile.ID] = i
+		}
+	}
+	return index
+}
+
+// MeasureDisruption は解が元の場をどれだけ崩したかを測る。
+// solution のタイルIDは SolveCheckmate と同じく、場→手札の順に振られている必要がある。
+func MeasureDisruption(board Board, solution []Meld) Disruption {
+	index := meldOf(solution)
+
+	var d Disruption
+	var id TileID
+	for _, meld := range board.Melds {
+		// 元のメルドのタイルが最も多く集まっている解のメルド以外へ移ったタイルを数える
+		count := make(map[int]int)
+		most := 0
+		for range meld {
+			count[index[id]]++
+			most = max(most, count[index[id]])
+			id++
+		}
+		if most == len(meld) {
+			d.Intact++
+		}
+		d.Moved += len(meld) - most
+	}
+	return d
+}
+
+// ClassifySolution は解の各メルドが元の場のメルドのままか、付け足したものか、新しいものかを判定する。
+// solution のタイルIDは SolveCheckmate と同じく、場→手札の順に振られている必要がある。
+func ClassifySolution(board Board, solution []Meld) []SolutionMeld {
+	index := meldOf(solution)
+
+	result := make([]SolutionMeld, len(solution))
+	for i, meld := range solution {
+		result[i] = SolutionMeld{Meld: meld, Change: MeldNew, Origin: -1}
+	}
+
+	var id TileID
+	for origin, meld := range board.Melds {
+		target := -1
+		for range meld {
+			dest, ok := index[id]
+			if !ok {
+				target = -2 // 解に含まれないタイル
+			} else if target == -1 {
+				target = dest
+			} else if dest != target {
+				target = -2
+			}
+			id++
+		}
+		if target < 0 || result[target].Origin != -1 {
+			continue
+		}
+
+		result[target].Origin = origin
+		if len(result[target].Meld) == len(meld) {
+			result[target].Change = MeldUnchanged
+		} else {
+			result[target].Change = MeldExtended
+		}
+	}
+	return result
+}
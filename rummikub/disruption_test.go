@@ -0,0 +1,140 @@
+package rummikub
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestSolveCheckmateMinimalDisruption_KeepsMelds(t *testing.T) {
+	// [Y1 Y2 Y3] を新しく出せば場は崩さなくてよい
+	board := Board{Melds: []Meld{
+		{R1, R2, R3},
+		{B1, B2, B3},
+	}}
+	hand := Hand{Tiles: []Tile{Y1, Y2, Y3}}
+
+	ok, solution := SolveCheckmateMinimalDisruption(board, hand)
+	if !ok {
+		t.Fatal("Expected checkmate, but got none")
+	}
+
+	changes := make(map[MeldChange]int)
+	for _, m := range solution {
+		changes[m.Change]++
+	}
+	if changes[MeldUnchanged] != 2 || changes[MeldNew] != 1 {
+		t.Errorf("Expected 2 unchanged and 1 new meld, got %v", changes)
+	}
+}
+
+func TestSolveCheckmateMinimalDisruption_Extended(t *testing.T) {
+	board := Board{Melds: []Meld{
+		{R1, R2, R3},
+		{R7, B7, Y7},
+	}}
+	hand := Hand{Tiles: []Tile{R4, K7}}
+
+	ok, solution := SolveCheckmateMinimalDisruption(board, hand)
+	if !ok {
+		t.Fatal("Expected checkmate, but got none")
+	}
+	for _, m := range solution {
+		if m.Change != MeldExtended {
+			t.Errorf("Expected %s to be extended, got %s", m.Meld, m.Change)
+		}
+	}
+}
+
+func TestSolveCheckmateMinimalDisruption_HeavyRearrangement(t *testing.T) {
+	p := findPosition(t, "HeavyRearrangement")
+
+	ok, solution := SolveCheckmateMinimalDisruption(p.board, p.hand)
+	if !ok {
+		t.Fatal("Expected checkmate, but got none")
+	}
+
+	melds := make([]Meld, len(solution))
+	for i, m := range solution {
+		melds[i] = m.Meld
+	}
+	checkSolutionTiles(t, p.board, p.hand, melds)
+
+	// 任意の解より崩し方が少ないこと
+	_, first := SolveCheckmate(p.board, p.hand)
+	if MeasureDisruption(p.board, first).better(MeasureDisruption(p.board, melds)) {
+		t.Errorf("Found a less disruptive solution than the minimal one")
+	}
+	for _, m := range solution {
+		t.Logf("%-9s %s", m.Change, m.Meld)
+	}
+}
+
+func TestMeasureDisruption(t *testing.T) {
+	board := Board{Melds: []Meld{
+		{R4, R5, R6, R7},
+		{K1, K2, K3},
+	}}
+	hand := Hand{Tiles: []Tile{B7, Y7}}
+	tiles := collectTiles(board, hand)
+
+	// R7 を [R7 B7 Y7] に移す
+	solution := []Meld{
+		{tiles[0], tiles[1], tiles[2]},
+		{tiles[3], tiles[7], tiles[8]},
+		{tiles[4], tiles[5], tiles[6]},
+	}
+	got := MeasureDisruption(board, solution)
+	if got != (Disruption{Intact: 1, Moved: 1}) {
+		t.Errorf("MeasureDisruption() = %+v, want {Intact:1 Moved:1}", got)
+	}
+}
+
+func TestPreferOrigins_Duplicates(t *testing.T) {
+	board := Board{Melds: []Meld{
+		{R1, R2, R3},
+		{R2, R3, R4},
+	}}
+	hand := Hand{Tiles: []Tile{R5}}
+	tiles := collectTiles(board, hand)
+
+	// R2 と R3 のコピーが入れ違っている。どちらの解のメルドも今のコピーでは
+	// もう一方の元のメルドのタイルが多いので、それを元にしてコピーを選ぶと入れ違いが直らない
+	solution := [][]Tile{
+		{tiles[0], tiles[3], tiles[4]},
+		{tiles[1], tiles[2], tiles[5], tiles[6]},
+	}
+	melds := toMelds(preferOrigins(solution, board, tiles, Tile.Notation))
+	if got := MeasureDisruption(board, melds); got != (Disruption{Intact: 2}) {
+		t.Errorf("MeasureDisruption(preferOrigins()) = %+v, want {Intact:2 Moved:0} (%v)", got, melds)
+	}
+	for i, meld := range melds {
+		if meldKey(meld) != meldKey(solution[i]) {
+			t.Errorf("preferOrigins() meld %d = %s, want the same tiles as %s", i+1, meld, Meld(solution[i]))
+		}
+	}
+}
+
+func TestAssignMax(t *testing.T) {
+	tests := []struct {
+		weight [][]int
+		want   []int
+	}{
+		{[][]int{{3, 2}, {2, 3}}, []int{0, 1}},
+		{[][]int{{5, 4}, {4, 0}}, []int{1, 0}},
+		{[][]int{{1}, {2}}, []int{-1, 0}},
+		{[][]int{{0, 1, 0}}, []int{1}},
+		{nil, []int{}},
+	}
+	for _, tt := range tests {
+		if got := assignMax(tt.weight); !slices.Equal(got, tt.want) {
+			t.Errorf("assignMax(%v) = %v, want %v", tt.weight, got, tt.want)
+		}
+	}
+}
+
+func TestClassifySolution_NoSolution(t *testing.T) {
+	board := Board{Melds: []Meld{{R1, R2, R3}}}
+	if got := ClassifySolution(board, nil); len(got) != 0 {
+		t.Errorf("ClassifySolution(nil) = %v, want empty", got)
+	}
+}
@@ -0,0 +1,201 @@
+package rummikub
+
+// dlxNode は Dancing Links の双方向リンクリストのノード
+type dlxNode struct {
+	left, right, up, down int
+	column                int // 所属する列（ヘッダ）のノード番号
+	row                   int // 所属する行（候補）の番号。ヘッダは -1
+}
+
+// dlx は Knuth の Algorithm X を Dancing Links で実装した Exact Cover ソルバー。
+// 列がタイル、行が候補セットに対応する。
+type dlx struct {
+	nodes      []dlxNode
+	size       []int // 列ごとの残り行数（ヘッダのノード番号で引く）
+	candidates []candidateInfo
+	solution   []int
+	copies     []int   // copyOrder の結果
+	used       tileSet // 選んだ行が使っているタイル
+	accept     acceptFunc
+	mon        *monitor
+
+	memo   *failMemo // 解のなかった部分局面。nil なら覚えない
+	hashes []memoKey // 行ごとのハッシュ（candidateHashes）
+	key    memoKey   // 選んだ行が使っているタイルのハッシュ
+
+	enum *enumeration // 解をすべて列挙するときの状態。nil なら最初の解で止まる
+}
+
+// newDLX はタイル数 n と候補から DLX の行列を組み立てる
+func newDLX(n int, candidates []candidateInfo, copies []int, accept acceptFunc, mon *monitor) *dlx {
+	d := &dlx{
+		nodes:      make([]dlxNode, n+1),
+		size:       make([]int, n+1),
+		candidates: candidates,
+		copies:     copies,
+		solution:   make([]int, 0, n),
+		used:       newTileSet(n),
+		accept:     accept,
+		mon:        mon,
+	}
+
+	// ノード0がルート、1..nが列ヘッダ
+	for i := 0; i <= n; i++ {
+		d.nodes[i] = dlxNode{left: i - 1, right: i + 1, up: i, down: i, column: i, row: -1}
+	}
+	d.nodes[0].left = n
+	d.nodes[n].right = 0
+
+	for r, candidate := range candidates {
+		first := -1
+		for _, idx := range candidate.indices {
+			col := idx + 1
+			node := len(d.nodes)
+			d.nodes = append(d.nodes, dlxNode{
+				up:     d.nodes[col].up,
+				down:   col,
+				column: col,
+				row:    r,
+			})
+			d.nodes[d.nodes[col].up].down = node
+			d.nodes[col].up = node
+			d.size[col]++
+
+			if first == -1 {
+				first = node
+				d.nodes[node].left = node
+				d.nodes[node].right = node
+			} else {
+				d.nodes[node].left = d.nodes[first].left
+				d.nodes[node].right = first
+				d.nodes[d.nodes[first].left].right = node
+				d.nodes[first].left = node
+			}
+		}
+	}
+	return d
+}
+
+// cover は列 c とその列を含む行を行列から取り除く
+func (d *dlx) cover(c int) {
+	nodes := d.nodes
+	nodes[nodes[c].right].left = nodes[c].left
+	nodes[nodes[c].left].right = nodes[c].right
+	for i := nodes[c].down; i != c; i = nodes[i].down {
+		for j := nodes[i].right; j != i; j = nodes[j].right {
+			nodes[nodes[j].down].up = nodes[j].up
+			nodes[nodes[j].up].down = nodes[j].down
+			d.size[nodes[j].column]--
+		}
+	}
+}
+
+// uncover は cover の逆操作
+func (d *dlx) uncover(c int) {
+	nodes := d.nodes
+	for i := nodes[c].up; i != c; i = nodes[i].up {
+		for j := nodes[i].left; j != i; j = nodes[j].left {
+			d.size[nodes[j].column]++
+			nodes[nodes[j].down].up = j
+			nodes[nodes[j].up].down = j
+		}
+	}
+	nodes[nodes[c].right].left = c
+	nodes[nodes[c].left].right = c
+}
+
+// search は残りの列を覆う行の組み合わせを探す。
+// 枝分かれを減らすため、残り行数が最小の列から選ぶ（MRVヒューリスティック）。
+func (d *dlx) search() bool {
+	if !d.mon.visit() {
+		return false
+	}
+	nodes := d.nodes
+	if nodes[0].right == 0 {
+		if d.enum != nil {
+			return d.enum.leaf(d.solutionTiles())
+		}
+		return d.accept == nil || d.accept(d.solutionTiles())
+	}
+
+	c := -1
+	for j := nodes[0].right; j != 0; j = nodes[j].right {
+		if c == -1 || d.size[j] < d.size[c] {
+			c = j
+		}
+	}
+	if d.size[c] == 0 {
+		return false
+	}
+	if d.memo != nil {
+		hit := d.memo.contains(d.key)
+		d.mon.probe(hit)
+		if hit {
+			return false
+		}
+	}
+
+	mark := d.enum.mark()
+	d.cover(c)
+	for r := nodes[c].down; r != c; r = nodes[r].down {
+		candidate := &d.candidates[nodes[r].row]
+		if !lowestCopies(*candidate, c-1, d.copies, d.used) || !d.enum.allows(nodes[r].row, *candidate) {
+			continue
+		}
+
+		prev := d.enum.choose(nodes[r].row, c-1)
+		d.solution = append(d.solution, nodes[r].row)
+		d.used.union(candidate.mask)
+		if d.memo != nil {
+			d.key = d.key.plus(d.hashes[nodes[r].row])
+		}
+		for j := nodes[r].right; j != r; j = nodes[j].right {
+			d.cover(nodes[j].column)
+		}
+
+		if d.search() {
+			return true
+		}
+
+		for j := nodes[r].left; j != r; j = nodes[j].left {
+			d.uncover(nodes[j].column)
+		}
+		if d.memo != nil {
+			d.key = d.key.minus(d.hashes[nodes[r].row])
+		}
+		d.used.subtract(candidate.mask)
+		d.solution = d.solution[:len(d.solution)-1]
+		d.enum.restore(c-1, prev)
+		d.mon.undo()
+		if d.mon.stopped() {
+			break
+		}
+	}
+	d.uncover(c)
+	if d.memo != nil && !d.mon.stopped() && d.enum.failed(mark) {
+		d.memo.add(d.key)
+	}
+	return false
+}
+
+// solutionTiles は選んだ行を候補セットのタイルに変換する
+func (d *dlx) solutionTiles() [][]Tile {
+	solution := make([][]Tile, len(d.solution))
+	for i, r := range d.solution {
+		solution[i] = d.candidates[r].tiles
+	}
+	return solution
+}
+
+// dlxCover は Dancing Links で Exact Cover 問題を解く
+func dlxCover(tiles []Tile, infos []candidateInfo, copies []int, accept acceptFunc, mon *monitor) ([][]Tile, bool) {
+	d := newDLX(len(tiles), infos, copies, accept, mon)
+	// exactCover と同じく、解のなかった部分局面を覚える
+	if d.hashes = mon.memoHashes(tiles, infos, accept); d.hashes != nil {
+		d.memo = newFailMemo(mon.memoSize)
+	}
+	if d.search() {
+		return d.solutionTiles(), true
+	}
+	return nil, false
+}
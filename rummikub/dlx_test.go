@@ -0,0 +1,91 @@
+package rummikub
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestSolveCheckmate_Engines(t *testing.T) {
+	for _, engine := range []Engine{EngineBacktrack, EngineDLX} {
+		for _, p := range testPositions {
+			t.Run(engine.String()+"/"+p.name, func(t *testing.T) {
+				opts := DefaultOptions()
+				opts.Engine = engine
+				gs := &GameState{Board: p.board, Hand: p.hand, Opened: true}
+
+				got, solution := SolveCheckmateWithOptions(gs, opts)
+				if got != p.checkmate {
+					t.Fatalf("SolveCheckmateWithOptions() = %v, want %v", got, p.checkmate)
+				}
+				if got {
+					checkSolutionTiles(t, p.board, p.hand, solution)
+				}
+			})
+		}
+	}
+}
+
+func TestSolveCheckmate_EnginesAgreeOnGenerated(t *testing.T) {
+	for seed := uint64(1); seed <= 20; seed++ {
+		board, hand := generatePosition(seed, 30, 2)
+		gs := &GameState{Board: board, Hand: hand, Opened: true}
+
+		for _, engine := range []Engine{EngineBacktrack, EngineDLX} {
+			opts := DefaultOptions()
+			opts.Engine = engine
+			got, solution := SolveCheckmateWithOptions(gs, opts)
+			if !got {
+				t.Errorf("seed %d: %s found no checkmate for a generated solvable position", seed, engine)
+				continue
+			}
+			checkSolutionTiles(t, board, hand, solution)
+		}
+	}
+}
+
+func BenchmarkSolveCheckmate(b *testing.B) {
+	positions := testPositions
+	for _, size := range []int{40, 60} {
+		board, hand := generatePosition(uint64(size), size, 2)
+		positions = append(positions, testPosition{
+			name:  fmt.Sprintf("Generated%d", size),
+			board: board,
+			hand:  hand,
+		})
+	}
+
+	// カタログを作る時間を最初の局面に含めない
+	catalogFor(DefaultRules().withDefaults())
+
+	for _, p := range positions {
+		for _, engine := range []Engine{EngineBacktrack, EngineDLX} {
+			b.Run(p.name+"/"+engine.String(), func(b *testing.B) {
+				b.ReportAllocs()
+				opts := DefaultOptions()
+				opts.Engine = engine
+				gs := &GameState{Board: p.board, Hand: p.hand, Opened: true}
+				for b.Loop() {
+					SolveCheckmateWithOptions(gs, opts)
+				}
+			})
+		}
+	}
+}
+
+func TestSolveCheckmate_DuplicateCopies(t *testing.T) {
+	for seed := uint64(1); seed <= 20; seed++ {
+		board, hand := generatePositionWithCopies(seed, 40, 2, 2)
+		gs := &GameState{Board: board, Hand: hand, Opened: true}
+
+		for _, engine := range []Engine{EngineBacktrack, EngineDLX} {
+			opts := DefaultOptions()
+			opts.Engine = engine
+			got, solution := SolveCheckmateWithOptions(gs, opts)
+			if !got {
+				t.Errorf("seed %d: %s found no checkmate for a generated solvable position", seed, engine)
+				continue
+			}
+			checkSolutionTiles(t, board, hand, solution)
+		}
+	}
+}
@@ -0,0 +1,4 @@
+// Package rummikub はラミィキューブの盤面・手札を表現し、詰み判定を行うライブラリ
+//
+// 詰みとは、場のメルドを自由に組み替えて手札をすべて出し切れる状態を指す。
+package rummikub
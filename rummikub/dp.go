@@ -0,0 +1,224 @@
+package rummikub
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// dpRun は数字を昇順に見ていく途中で、まだ閉じていないラン
+type dpRun []Tile
+
+// dpSolver は数字を小さい順に走査する動的計画法による詰み判定。
+// 各数字で、色ごとのタイル（とジョーカー）を「開いているランを伸ばす」
+// 「新しいランを始める」「グループに入れる」に振り分ける。
+// 今後の可否は (数字, 色ごとの開いているランの長さ, 残りジョーカー数) だけで決まるので、
+// 失敗した状態をメモ化して同じ状態を再探索しない。
+type dpSolver struct {
+	rules  Rules
+	tiles  [][][]Tile // [色の番号][数字 - MinNumber]
+	jokers []Tile
+	failed map[string]bool
+	melds  []Meld
+}
+
+// SolveCheckmateDP は SolveCheckmate と同じ詰み判定を、
+// タイルを (色, 数字) ごとの枚数として扱う動的計画法で行う
+func SolveCheckmateDP(board Board, hand Hand) (bool, []Meld) {
+	return SolveCheckmateDPWithRules(board, hand, DefaultRules())
+}
+
+// SolveCheckmateDPWithRules は指定したルールで SolveCheckmateDP を行う。
+// 折り返しのあるランは数字を一方向に走査できないので、WrapRuns が true なら
+// SolveCheckmateWithOptions で判定する。
+func SolveCheckmateDPWithRules(board Board, hand Hand, r Rules) (bool, []Meld) {
+	r = r.withDefaults()
+	if r.WrapRuns {
+		gs := &GameState{Board: board, Hand: hand, Opened: true}
+		return SolveCheckmateWithOptions(gs, Options{Rules: r})
+	}
+
+	allTiles := collectTiles(board, hand)
+	if len(allTiles) == 0 {
+		return true, nil
+	}
+
+	s := &dpSolver{
+		rules:  r,
+		tiles:  make([][][]Tile, len(r.Colors)),
+		failed: make(map[string]bool),
+	}
+	for c := range s.tiles {
+		s.tiles[c] = make([][]Tile, r.numberCount())
+	}
+	for _, tile := range allTiles {
+		if tile.IsJoker {
+			s.jokers = append(s.jokers, tile)
+			continue
+		}
+		c := slices.Index(r.Colors, tile.Color)
+		if c < 0 || !r.validTile(tile) {
+			return false, nil // ルールのデッキにないタイルはメルドにできない
+		}
+		s.tiles[c][tile.Number-r.MinNumber] = append(s.tiles[c][tile.Number-r.MinNumber], tile)
+	}
+
+	if !s.solve(0, make([][]dpRun, len(r.Colors)), 0) {
+		return false, nil
+	}
+	return true, s.melds
+}
+
+// solve は n 番目（MinNumber からの位置）以降の数字のタイルをすべてメルドに収められるかを判定する。
+// runs は1つ前の数字まで伸びている色ごとのラン、jokersUsed は使用済みジョーカー数。
+func (s *dpSolver) solve(n int, runs [][]dpRun, jokersUsed int) bool {
+	if n == s.rules.numberCount() {
+		if jokersUsed != len(s.jokers) {
+			return false
+		}
+		for _, colorRuns := range runs {
+			for _, run := range colorRuns {
+				if len(run) < s.rules.MinMeldSize {
+					return false
+				}
+				s.melds = append(s.melds, Meld(run))
+			}
+		}
+		return true
+	}
+
+	key := s.key(n, runs, jokersUsed)
+	if s.failed[key] {
+		return false
+	}
+
+	next := make([][]dpRun, len(runs))
+	groups := make([][]Tile, len(runs))
+	if s.assign(n, 0, runs, next, groups, jokersUsed) {
+		return true
+	}
+	s.failed[key] = true
+	return false
+}
+
+// assign は数字 n の色 c 以降のタイルの振り分けを決める。
+// next には振り分け後のラン、groups にはグループに入れるタイルが色ごとに入る。
+func (s *dpSolver) assign(n int, c int, runs [][]dpRun, next [][]dpRun, groups [][]Tile, jokersUsed int) bool {
+	if c == len(runs) {
+		formed, ok := formGroups(groups, s.rules.MinMeldSize)
+		if !ok {
+			return false
+		}
+		mark := len(s.melds)
+		s.melds = append(s.melds, formed...)
+		if s.solve(n+1, next, jokersUsed) {
+			return true
+		}
+		s.melds = s.melds[:mark]
+		return false
+	}
+
+	// 最小枚数に満たないランは伸ばさないと無効なので必ず伸ばす。
+	// 最小枚数以上のランは伸ばしても閉じてもよい。
+	var short, long []dpRun
+	for _, run := range runs[c] {
+		if len(run) < s.rules.MinMeldSize {
+			short = append(short, run)
+		} else {
+			long = append(long, run)
+		}
+	}
+
+	for j := 0; jokersUsed+j <= len(s.jokers); j++ {
+		instances := slices.Concat(s.tiles[c][n], s.jokers[jokersUsed:jokersUsed+j])
+
+		for g := 0; g <= len(instances); g++ {
+			inRun := instances[g:]
+			if len(inRun) < len(short) {
+				continue
+			}
+			extra := len(inRun) - len(short)
+
+			for e := 0; e <= min(extra, len(long)); e++ {
+				var colorRuns []dpRun
+				i := 0
+				for _, run := range short {
+					colorRuns = append(colorRuns, append(slices.Clip(run), inRun[i]))
+					i++
+				}
+				for _, run := range long[:e] {
+					colorRuns = append(colorRuns, append(slices.Clip(run), inRun[i]))
+					i++
+				}
+				for ; i < len(inRun); i++ {
+					colorRuns = append(colorRuns, dpRun{inRun[i]})
+				}
+
+				// 伸ばさなかった長いランはここで閉じる
+				mark := len(s.melds)
+				for _, run := range long[e:] {
+					s.melds = append(s.melds, Meld(run))
+				}
+
+				next[c] = colorRuns
+				groups[c] = instances[:g]
+				if s.assign(n, c+1, runs, next, groups, jokersUsed+j) {
+					return true
+				}
+				s.melds = s.melds[:mark]
+			}
+		}
+	}
+	return false
+}
+
+// formGroups は色ごとのタイルを、同じ色を含まない minSize 枚以上・色の数以下のグループに分ける。
+// グループ数を G とすると、各色の枚数が G 以下で合計が minSize*G〜色の数*G なら、
+// 色ごとに順番に G 個のグループへ配ることで必ず分けられる。
+func formGroups(byColor [][]Tile, minSize int) ([]Meld, bool) {
+	total, most := 0, 0
+	for _, tiles := range byColor {
+		total += len(tiles)
+		most = max(most, len(tiles))
+	}
+	if total == 0 {
+		return nil, true
+	}
+
+	count := max(most, (total+len(byColor)-1)/len(byColor))
+	if total < minSize*count {
+		return nil, false
+	}
+
+	groups := make([]Meld, count)
+	i := 0
+	for _, tiles := range byColor {
+		for _, tile := range tiles {
+			groups[i%count] = append(groups[i%count], tile)
+			i++
+		}
+	}
+	return groups, true
+}
+
+// key はメモ化のキーを作る。ランの長さは最小枚数以上を区別しない。
+func (s *dpSolver) key(n int, runs [][]dpRun, jokersUsed int) string {
+	var b strings.Builder
+	b.WriteString(strconv.Itoa(n))
+	b.WriteByte(':')
+	b.WriteString(strconv.Itoa(jokersUsed))
+	for _, colorRuns := range runs {
+		lengths := make([]int, len(colorRuns))
+		for i, run := range colorRuns {
+			lengths[i] = min(len(run), s.rules.MinMeldSize)
+		}
+		slices.Sort(lengths)
+
+		b.WriteByte('|')
+		for _, l := range lengths {
+			b.WriteString(strconv.Itoa(l))
+			b.WriteByte(',')
+		}
+	}
+	return b.String()
+}
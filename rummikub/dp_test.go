@@ -0,0 +1,140 @@
+package rummikub
+
+import (
+	"math/rand/v2"
+	"testing"
+)
+
+// checkSolutionTiles は解がちょうど場と手札のタイルを使った有効なメルドからなるかを Verify で確認する
+func checkSolutionTiles(t *testing.T, board Board, hand Hand, solution []Meld) {
+	t.Helper()
+	checkSolution(t, &GameState{Board: board, Hand: hand, Opened: true}, DefaultRules(), solution)
+}
+
+// checkSolution は解をルールも含めて VerifyWithRules で確認する
+func checkSolution(t *testing.T, gs *GameState, r Rules, solution []Meld) {
+	t.Helper()
+	if err := VerifyWithRules(gs, solution, r); err != nil {
+		t.Errorf("VerifyWithRules(%v) = %v", solution, err)
+	}
+}
+
+func TestSolveCheckmateDP_Positions(t *testing.T) {
+	for _, p := range testPositions {
+		t.Run(p.name, func(t *testing.T) {
+			got, solution := SolveCheckmateDP(p.board, p.hand)
+			if got != p.checkmate {
+				t.Fatalf("SolveCheckmateDP() = %v, want %v", got, p.checkmate)
+			}
+			if got {
+				checkSolutionTiles(t, p.board, p.hand, solution)
+			}
+		})
+	}
+}
+
+func TestSolveCheckmateDP_DuplicateTiles(t *testing.T) {
+	// 2枚の R5 を別々のランに入れる必要がある
+	board := Board{Melds: []Meld{
+		{R3, R4, R5},
+		{R5, R6, R7},
+	}}
+	hand := Hand{Tiles: []Tile{R8}}
+
+	got, solution := SolveCheckmateDP(board, hand)
+	if !got {
+		t.Fatal("Expected checkmate, but got none")
+	}
+	checkSolutionTiles(t, board, hand, solution)
+}
+
+func TestSolveCheckmateDP_JokerGroups(t *testing.T) {
+	hand := Hand{Tiles: []Tile{R5, B5, JK, R9, Y9, JK}}
+
+	got, solution := SolveCheckmateDP(Board{}, hand)
+	if !got {
+		t.Fatal("Expected checkmate, but got none")
+	}
+	checkSolutionTiles(t, Board{}, hand, solution)
+}
+
+func TestSolveCheckmateDP_AgreesWithSolveCheckmate(t *testing.T) {
+	// 重複とジョーカーのない局面で既存のソルバーと比較する
+	var deck []Tile
+	for _, color := range []Color{Red, Blue, Yellow, Black} {
+		for n := TileNumber(1); n <= 13; n++ {
+			deck = append(deck, NewTile(color, n))
+		}
+	}
+
+	rng := rand.New(rand.NewPCG(1, 2))
+	for i := 0; i < 300; i++ {
+		rng.Shuffle(len(deck), func(a, b int) { deck[a], deck[b] = deck[b], deck[a] })
+		hand := Hand{Tiles: deck[:3+rng.IntN(7)]}
+
+		want, _ := SolveCheckmate(Board{}, hand)
+		got, solution := SolveCheckmateDP(Board{}, hand)
+		if got != want {
+			t.Fatalf("%s: SolveCheckmateDP() = %v, SolveCheckmate() = %v", hand.String(), got, want)
+		}
+		if got {
+			checkSolutionTiles(t, Board{}, hand, solution)
+		}
+	}
+
+	for seed := uint64(1); seed <= 20; seed++ {
+		board, hand := generatePosition(seed, 30, 2)
+		got, solution := SolveCheckmateDP(board, hand)
+		if !got {
+			t.Errorf("seed %d: SolveCheckmateDP() found no checkmate", seed)
+			continue
+		}
+		checkSolutionTiles(t, board, hand, solution)
+	}
+}
+
+func TestSolveCheckmateDP_AgreesOnDuplicates(t *testing.T) {
+	// 各タイル2枚ずつのデッキで、同じタイルのコピーを別々のメルドに入れる必要がある局面も比較する
+	var deck []Tile
+	for _, color := range []Color{Red, Blue, Yellow} {
+		for n := TileNumber(1); n <= 6; n++ {
+			deck = append(deck, NewTile(color, n), NewTile(color, n))
+		}
+	}
+
+	rng := rand.New(rand.NewPCG(3, 4))
+	found := 0
+	for i := 0; i < 300; i++ {
+		rng.Shuffle(len(deck), func(a, b int) { deck[a], deck[b] = deck[b], deck[a] })
+		hand := Hand{Tiles: deck[:6+rng.IntN(7)]}
+
+		want, _ := SolveCheckmateDP(Board{}, hand)
+		for _, engine := range []Engine{EngineBacktrack, EngineDLX} {
+			opts := DefaultOptions()
+			opts.Engine = engine
+			got, solution := SolveCheckmateWithOptions(&GameState{Hand: hand, Opened: true}, opts)
+			if got != want {
+				t.Fatalf("%s: %s = %v, SolveCheckmateDP() = %v", hand.String(), engine, got, want)
+			}
+			if got {
+				checkSolutionTiles(t, Board{}, hand, solution)
+			}
+		}
+		if want {
+			found++
+		}
+	}
+	if found == 0 {
+		t.Error("No solvable hands were generated")
+	}
+}
+
+func BenchmarkSolveCheckmateDP(b *testing.B) {
+	for _, p := range testPositions {
+		b.Run(p.name, func(b *testing.B) {
+			for b.Loop() {
+				SolveCheckmateDP(p.board, p.hand)
+			}
+		})
+	}
+}
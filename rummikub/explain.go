@@ -0,0 +1,63 @@
+package rummikub
+
+import (
+	"context"
+	"slices"
+)
+
+// Explanation は詰みがないとき、手札を出し切るのを妨げているタイル
+type Explanation struct {
+	// Hold は手札に残せば残りの手札を出し切れるタイルのうち、枚数が最も少ない組（SolveMaxPlay で出せないタイル）
+	Hold []Tile
+	// Core は場と合わせても一緒には出し切れない手札のタイル。どの1枚を手札に残しても、残りは出し切れる
+	Core []Tile
+}
+
+// ExplainNoCheckmate は詰みがない理由を調べる。
+// 詰みがあるか、場のタイルだけで有効なメルドを組めなければ false を返す。
+func ExplainNoCheckmate(gs *GameState, opts Options) (Explanation, bool) {
+	ex, ok, _ := ExplainNoCheckmateContext(context.Background(), gs, opts)
+	return ex, ok
+}
+
+// ExplainNoCheckmateContext は ctx の期限とキャンセルに従って ExplainNoCheckmate を行う。
+// 打ち切ったときは ctx.Err() を返す。
+//
+// Core は手札全体から始め、1枚除いても出し切れないタイルを除くことを、除けるタイルがなくなるまで繰り返して求める。
+// 手札を増やすと出し切れるようになることもあるので、除くたびに残りのタイルを確かめ直す。
+func ExplainNoCheckmateContext(ctx context.Context, gs *GameState, opts Options) (Explanation, bool, error) {
+	opts.Stats = nil
+	solvable := func(hand []Tile) (bool, error) {
+		sub := &GameState{Board: gs.Board, Hand: Hand{Tiles: hand}, Opened: gs.Opened}
+		ok, _, err := findCheckmateContext(ctx, sub, opts, nil)
+		return ok, err
+	}
+
+	ok, err := solvable(gs.Hand.Tiles)
+	if ok || err != nil {
+		return Explanation{}, false, err
+	}
+	maxPlay, ok, err := SolveMaxPlayContext(ctx, gs, opts)
+	if !ok || err != nil {
+		return Explanation{}, false, err
+	}
+
+	core := slices.Clone(gs.Hand.Tiles)
+	for removed := true; removed; {
+		removed = false
+		for i := 0; i < len(core); {
+			rest := slices.Delete(slices.Clone(core), i, i+1)
+			ok, err := solvable(rest)
+			if err != nil {
+				return Explanation{}, false, err
+			}
+			if ok {
+				i++
+			} else {
+				core = rest
+				removed = true
+			}
+		}
+	}
+	return Explanation{Hold: maxPlay.Hand.Tiles, Core: core}, true, nil
+}
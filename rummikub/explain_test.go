@@ -0,0 +1,124 @@
+package rummikub
+
+import (
+	"context"
+	"errors"
+	"math"
+	"slices"
+	"testing"
+)
+
+func TestExplainNoCheckmate(t *testing.T) {
+	tests := []struct {
+		name   string
+		board  Board
+		hand   []Tile
+		opened bool
+		hold   []Tile
+		core   []Tile
+	}{
+		{"lone tile", Board{Melds: []Meld{{R1, R2, R3}}}, []Tile{R4, K9}, true, []Tile{K9}, []Tile{K9}},
+		{"pair", Board{Melds: []Meld{{R1, R2, R3}}}, []Tile{R4, R5, B7, B8}, true, []Tile{B7, B8}, []Tile{B8}},
+		{"opening points", Board{Melds: []Meld{{K10, K11, K12}}}, []Tile{R1, R2, R3, K9}, false, []Tile{R1, R2, R3, K9}, []Tile{K9}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gs := &GameState{Board: tt.board, Hand: Hand{Tiles: tt.hand}, Opened: tt.opened}
+			ex, ok := ExplainNoCheckmate(gs, DefaultOptions())
+			if !ok {
+				t.Fatalf("ExplainNoCheckmate() = false")
+			}
+			if meldKey(ex.Hold) != meldKey(tt.hold) || meldKey(ex.Core) != meldKey(tt.core) {
+				t.Errorf("ExplainNoCheckmate() = hold %v core %v, want hold %v core %v", Meld(ex.Hold), Meld(ex.Core), Meld(tt.hold), Meld(tt.core))
+			}
+			checkExplanation(t, gs, ex)
+		})
+	}
+
+	p := findPosition(t, "SimpleRun")
+	gs := &GameState{Board: p.board, Hand: p.hand, Opened: true}
+	if _, ok := ExplainNoCheckmate(gs, DefaultOptions()); ok {
+		t.Errorf("ExplainNoCheckmate() = true for a position with checkmate")
+	}
+}
+
+func TestExplainNoCheckmate_Generated(t *testing.T) {
+	for seed := uint64(1); seed <= 10; seed++ {
+		board, hand := generatePosition(seed, 20, 1)
+		hand.Tiles = append(hand.Tiles, R1, K13)
+		gs := &GameState{Board: board, Hand: hand, Opened: true}
+		ex, ok := ExplainNoCheckmate(gs, DefaultOptions())
+		if !ok {
+			t.Fatalf("seed %d: ExplainNoCheckmate() = false", seed)
+		}
+		checkExplanation(t, gs, ex)
+	}
+}
+
+func TestExplainNoCheckmateContext_Deadline(t *testing.T) {
+	// 最も多く出せる手の探索が2回 ctx を確かめる局面。最初の詰み判定が確かめる回数を数えて、
+	// その次の探索（最も多く出せる手）の途中で期限が切れるようにする
+	board, hand := generatePosition(1, 50, 1)
+	hand.Tiles = append(hand.Tiles, Y4, B13, B3, Y8)
+	gs := &GameState{Board: board, Hand: hand, Opened: true}
+
+	counter := &expiringContext{Context: context.Background(), limit: math.MaxInt}
+	if ok, _, _ := findCheckmateContext(counter, gs, DefaultOptions(), nil); ok {
+		t.Fatal("Expected no checkmate")
+	}
+	ctx := &expiringContext{Context: context.Background(), limit: counter.calls + 1}
+	if _, ok, err := ExplainNoCheckmateContext(ctx, gs, DefaultOptions()); ok || !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("ExplainNoCheckmateContext() = %v, %v, want context.DeadlineExceeded", ok, err)
+	}
+	if _, ok, err := ExplainNoCheckmateContext(context.Background(), gs, DefaultOptions()); !ok || err != nil {
+		t.Errorf("ExplainNoCheckmateContext() = %v, %v without a deadline", ok, err)
+	}
+}
+
+// checkExplanation は Hold を残せば出し切れること、それより少ない枚数では出し切れないこと、
+// Core は出し切れず、どの1枚を除いても出し切れることを確かめる
+func checkExplanation(t *testing.T, gs *GameState, ex Explanation) {
+	t.Helper()
+	solvable := func(hand []Tile) bool {
+		ok, _ := SolveCheckmateWithOptions(&GameState{Board: gs.Board, Hand: Hand{Tiles: hand}, Opened: gs.Opened}, DefaultOptions())
+		return ok
+	}
+	without := func(hand, removed []Tile) []Tile {
+		rest := slices.Clone(hand)
+		for _, tile := range removed {
+			i := slices.IndexFunc(rest, func(x Tile) bool { return x.Notation() == tile.Notation() })
+			rest = slices.Delete(rest, i, i+1)
+		}
+		return rest
+	}
+
+	if !solvable(without(gs.Hand.Tiles, ex.Hold)) {
+		t.Errorf("hand without hold %v is not solvable", Meld(ex.Hold))
+	}
+	if len(gs.Hand.Tiles) <= 8 {
+		for mask := 0; mask < 1<<len(gs.Hand.Tiles); mask++ {
+			var rest []Tile
+			held := 0
+			for i, tile := range gs.Hand.Tiles {
+				if mask&(1<<i) != 0 {
+					held++
+				} else {
+					rest = append(rest, tile)
+				}
+			}
+			if held < len(ex.Hold) && solvable(rest) {
+				t.Errorf("holding %d tiles is enough, but hold is %v", held, Meld(ex.Hold))
+				break
+			}
+		}
+	}
+
+	if len(ex.Core) == 0 || solvable(ex.Core) {
+		t.Errorf("core %v is solvable", Meld(ex.Core))
+	}
+	for i := range ex.Core {
+		if rest := slices.Delete(slices.Clone(ex.Core), i, i+1); !solvable(rest) {
+			t.Errorf("core %v is not minimal: %v is not solvable", Meld(ex.Core), Meld(rest))
+		}
+	}
+}
@@ -0,0 +1,297 @@
+package rummikub
+
+import "slices"
+
+// JokerReplacements はメルドの i 番目のジョーカーが代わりをしているタイルの候補を返す。
+// 場のジョーカーは、このいずれかのタイルと入れ替えたときだけ取り出せる。
+// ランはタイルが並んでいる順に数字を割り当て、順に並んでいなければ埋められる数字をすべて候補とする。
+// グループは足りない色がすべて候補になる。
+func (m Meld) JokerReplacements(i int) []Tile {
+	return m.JokerReplacementsWith(i, DefaultRules())
+}
+
+// JokerReplacementsWith は指定したルールで JokerReplacements を行う
+func (m Meld) JokerReplacementsWith(i int, r Rules) []Tile {
+	r = r.withDefaults()
+	if i < 0 || i >= len(m) || !m[i].IsJoker {
+		return nil
+	}
+
+	var result []Tile
+	if m.isValidSet(r) {
+		result = append(result, m.setReplacements(r)...)
+	}
+	if m.isValidRun(r) {
+		for _, tile := range m.runReplacements(i, r) {
+			if !slices.Contains(result, tile) {
+				result = append(result, tile)
+			}
+		}
+	}
+	return result
+}
+
+// CanSwapJoker はメルドの i 番目のジョーカーをタイル t と入れ替えて取り出せるかを返す
+func (m Meld) CanSwapJoker(i int, t Tile) bool {
+	return m.CanSwapJokerWith(i, t, DefaultRules())
+}
+
+// CanSwapJokerWith は指定したルールで CanSwapJoker を行う
+func (m Meld) CanSwapJokerWith(i int, t Tile, r Rules) bool {
+	for _, rep := range m.JokerReplacementsWith(i, r) {
+		if rep.Color == t.Color && rep.Number == t.Number && !t.IsJoker {
+			return true
+		}
+	}
+	return false
+}
+
+// JokerTiles はメルドのジョーカーがそれぞれ何のタイルの代わりをしているかを、ジョーカーの並び順に返す。
+// 割り当て方は ResolveWith（並び順を問わない場合）と同じ。無効なメルドなら nil を返す。
+func (m Meld) JokerTiles() []Tile {
+	return m.JokerTilesWith(DefaultRules())
+}
+
+// JokerTilesWith は指定したルールで JokerTiles を行う
+func (m Meld) JokerTilesWith(r Rules) []Tile {
+	res, err := m.ResolveWith(r, false)
+	if err != nil {
+		return nil
+	}
+	return res.Jokers()
+}
+
+// setReplacements はグループのジョーカーが代わりをできる、足りない色のタイルを返す
+func (m Meld) setReplacements(r Rules) []Tile {
+	var number TileNumber
+	present := make(map[Color]bool)
+	for _, tile := range m {
+		if !tile.IsJoker {
+			number = tile.Number
+			present[tile.Color] = true
+		}
+	}
+	if number == 0 {
+		return nil
+	}
+
+	var result []Tile
+	for _, color := range r.Colors {
+		if !present[color] {
+			result = append(result, NewTile(color, number))
+		}
+	}
+	return result
+}
+
+// runReplacements はランの i 番目のジョーカーが代わりをできるタイルを返す
+func (m Meld) runReplacements(i int, r Rules) []Tile {
+	var color Color
+	hasTile := false
+	for _, tile := range m {
+		if !tile.IsJoker {
+			color = tile.Color
+			hasTile = true
+		}
+	}
+	if !hasTile {
+		return nil
+	}
+
+	// 並び順どおりなら位置から数字が決まる
+	if start, ok := m.orderedStart(r); ok {
+		return []Tile{NewTile(color, r.runNumber(start, i))}
+	}
+
+	// 並んでいなければ、ジョーカーが入りうる数字をすべて候補にする
+	present := make(map[TileNumber]bool)
+	for _, tile := range m {
+		if !tile.IsJoker {
+			present[tile.Number] = true
+		}
+	}
+	var result []Tile
+	for _, start := range m.runStarts(r) {
+		for k := 0; k < len(m); k++ {
+			n := r.runNumber(start, k)
+			tile := NewTile(color, n)
+			if !present[n] && !slices.Contains(result, tile) {
+				result = append(result, tile)
+			}
+		}
+	}
+	slices.SortFunc(result, func(a, b Tile) int { return int(a.Number - b.Number) })
+	return result
+}
+
+// orderedStart はタイルが並んでいる順のまま数字が連続するランの開始の数字を返す
+func (m Meld) orderedStart(r Rules) (TileNumber, bool) {
+	for _, start := range m.runStarts(r) {
+		ordered := true
+		for k, tile := range m {
+			if !tile.IsJoker && tile.Number != r.runNumber(start, k) {
+				ordered = false
+				break
+			}
+		}
+		if ordered {
+			return start, true
+		}
+	}
+	return 0, false
+}
+
+// jokerRule は場のジョーカーの取り出しが公式ルールに沿っているかの判定。
+// 場のジョーカーは、元のメルドのタイルと一緒に同じタイルの代わりのまま残っているか、
+// 代わりをしていたタイルが元のメルドのタイルと同じメルドに入り（入れ替え）、
+// かつジョーカー自身が元のメルドとは別の、手札のタイルを含むメルドで使われていなければならない。
+type jokerRule struct {
+	jokers     []boardJoker
+	boardCount int
+	rules      Rules
+}
+
+// boardJoker は場のジョーカーと、その元のメルドの情報
+type boardJoker struct {
+	id           TileID
+	mates        []TileID // 元のメルドの他のタイル
+	replacements []Tile
+	hashes       [3]memoKey // 残っている・取り出した・入れ替えたメルドがあるときに状態に加える値
+}
+
+// newJokerRule は場のジョーカーの判定を返す。場にジョーカーがなければ nil を返す。
+func newJokerRule(board Board, allTiles []Tile, boardCount int, rules Rules) *jokerRule {
+	var jokers []boardJoker
+	for _, meld := range boardMelds(board, allTiles) {
+		for k, tile := range meld {
+			if !tile.IsJoker {
+				continue
+			}
+			j := boardJoker{id: tile.ID, replacements: meld.JokerReplacementsWith(k, rules)}
+			for _, mate := range meld {
+				if mate.ID != tile.ID {
+					j.mates = append(j.mates, mate.ID)
+				}
+			}
+			jokers = append(jokers, j)
+		}
+	}
+	if len(jokers) == 0 {
+		return nil
+	}
+	hashes := stateHashes(jokerSalt, 3*len(jokers))
+	for i := range jokers {
+		jokers[i].hashes = [3]memoKey(hashes[3*i : 3*i+3])
+	}
+	return &jokerRule{jokers: jokers, boardCount: boardCount, rules: rules}
+}
+
+// accept は解の場のジョーカーがすべてルールに沿っているかを返す
+func (jr *jokerRule) accept(solution [][]Tile) bool {
+	index := meldOf(toMelds(solution))
+	for _, j := range jr.jokers {
+		target, ok := index[j.id]
+		if !ok {
+			return false
+		}
+		meld := Meld(solution[target])
+
+		// 元のメルドのタイルと一緒に、同じタイルの代わりのまま残っていればよい
+		withMate := jr.withMate(meld, j)
+		if withMate && jr.stays(meld, j) {
+			continue
+		}
+
+		// 取り出したジョーカーは、元のメルドとは別の手札のタイルを含む新しいメルドで使う
+		if withMate || !jr.withHand(meld) || !replaced(solution, index, j.mates, j.replacements) {
+			return false
+		}
+	}
+	return true
+}
+
+// allows は場のジョーカーを含む候補を、そのジョーカーがルールに沿うメルドとして使えるかを返す。
+// 元のメルドのタイルを含むならジョーカーは同じタイルの代わりのまま残っていなければならず、
+// 含まないなら取り出したジョーカーなので手札のタイルを含んでいなければならない。
+// どちらも候補だけで決まるので、使えない候補は探索しなくてよい。
+func (jr *jokerRule) allows(tiles []Tile) bool {
+	for _, j := range jr.jokers {
+		if !slices.ContainsFunc(tiles, func(t Tile) bool { return t.ID == j.id }) {
+			continue
+		}
+		if jr.withMate(tiles, j) {
+			if !jr.stays(tiles, j) {
+				return false
+			}
+		} else if !jr.withHand(tiles) {
+			return false
+		}
+	}
+	return true
+}
+
+// state は候補を選んだときに判定の状態に加える値を返す。
+// allows を満たす候補だけを使うなら、accept の結果はジョーカーごとに、残したか取り出したかと、
+// 入れ替えたメルド（元のメルドのタイルと代わりをしていたタイルを含むメルド）の数だけで決まる。
+func (jr *jokerRule) state(tiles []Tile) memoKey {
+	var k memoKey
+	for _, j := range jr.jokers {
+		if slices.ContainsFunc(tiles, func(t Tile) bool { return t.ID == j.id }) {
+			if jr.withMate(tiles, j) {
+				k = k.plus(j.hashes[0])
+			} else {
+				k = k.plus(j.hashes[1])
+			}
+		}
+		if jr.withMate(tiles, j) && slices.ContainsFunc(tiles, func(t Tile) bool { return !t.IsJoker && isReplacement(t, j.replacements) }) {
+			k = k.plus(j.hashes[2])
+		}
+	}
+	return k
+}
+
+// withMate はタイルの組に j の元のメルドのタイルが含まれるかを返す
+func (jr *jokerRule) withMate(tiles []Tile, j boardJoker) bool {
+	return slices.ContainsFunc(tiles, func(t Tile) bool { return slices.Contains(j.mates, t.ID) })
+}
+
+// withHand はタイルの組に手札のタイルが含まれるかを返す
+func (jr *jokerRule) withHand(tiles []Tile) bool {
+	return slices.ContainsFunc(tiles, func(t Tile) bool { return int(t.ID) >= jr.boardCount })
+}
+
+// stays はタイルの組の中で、j が元と同じタイルの代わりのままかを返す
+func (jr *jokerRule) stays(tiles []Tile, j boardJoker) bool {
+	pos := slices.IndexFunc(tiles, func(t Tile) bool { return t.ID == j.id })
+	return stillRepresents(Meld(tiles), pos, j.replacements, jr.rules)
+}
+
+// stillRepresents はメルドの pos 番目のジョーカーを replacements のいずれかに置き換えても
+// メルドが有効か、つまり元と同じタイルの代わりをしているとみなせるかを返す
+func stillRepresents(meld Meld, pos int, replacements []Tile, rules Rules) bool {
+	for _, r := range replacements {
+		swapped := slices.Clone(meld)
+		swapped[pos] = r
+		if swapped.IsValidWith(rules) {
+			return true
+		}
+	}
+	return false
+}
+
+// replaced はジョーカーが代わりをしていたタイルが、元のメルドのタイルと同じメルドに入っているかを返す
+func replaced(solution [][]Tile, index map[TileID]int, mates []TileID, replacements []Tile) bool {
+	for _, mate := range mates {
+		for _, tile := range solution[index[mate]] {
+			if !tile.IsJoker && isReplacement(tile, replacements) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// isReplacement は tile が replacements のいずれかと同じ種類かを返す
+func isReplacement(tile Tile, replacements []Tile) bool {
+	return slices.ContainsFunc(replacements, func(r Tile) bool { return tile.Color == r.Color && tile.Number == r.Number })
+}
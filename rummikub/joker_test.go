@@ -0,0 +1,169 @@
+package rummikub
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestMeld_JokerReplacements(t *testing.T) {
+	tests := []struct {
+		name string
+		meld Meld
+		i    int
+		want []Tile
+	}{
+		{"run middle", Meld{R4, JK, R6}, 1, []Tile{R5}},
+		{"run end", Meld{R3, R4, R5, JK}, 3, []Tile{R6}},
+		{"run start", Meld{JK, R2, R3}, 0, []Tile{R1}},
+		{"group", Meld{R5, B5, JK}, 2, []Tile{Y5, K5}},
+		{"four color group", Meld{R5, B5, JK, K5}, 2, []Tile{Y5}},
+		{"unordered run", Meld{R3, R2, JK}, 2, []Tile{R1, R4}},
+		{"not a joker", Meld{R4, JK, R6}, 0, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.meld.JokerReplacements(tt.i)
+			if len(got) != len(tt.want) {
+				t.Fatalf("JokerReplacements(%d) = %v, want %v", tt.i, got, tt.want)
+			}
+			for k := range got {
+				if !tt.meld.CanSwapJoker(tt.i, tt.want[k]) {
+					t.Errorf("CanSwapJoker(%d, %s) = false, want true", tt.i, tt.want[k])
+				}
+			}
+		})
+	}
+
+	if (Meld{R4, JK, R6}).CanSwapJoker(1, R7) {
+		t.Error("CanSwapJoker(1, R7) = true, want false")
+	}
+}
+
+func TestSolveCheckmate_JokerRetrieval(t *testing.T) {
+	board := Board{Melds: []Meld{
+		{R3, R4, R5, JK},
+	}}
+
+	tests := []struct {
+		name string
+		hand []Tile
+		free bool
+		want bool
+	}{
+		{"take without replacement", []Tile{B9, Y9}, false, false},
+		{"take without replacement (house rule)", []Tile{B9, Y9}, true, true},
+		{"replace with rack tile", []Tile{B9, Y9, R6}, false, true},
+		{"joker stays in place", []Tile{R7}, false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gs := &GameState{Board: board, Hand: Hand{Tiles: tt.hand}, Opened: true}
+			opts := DefaultOptions()
+			opts.Rules.FreeJokers = tt.free
+
+			got, solution := SolveCheckmateWithOptions(gs, opts)
+			if got != tt.want {
+				t.Fatalf("SolveCheckmateWithOptions() = %v, want %v (solution %v)", got, tt.want, solution)
+			}
+			if got {
+				checkSolution(t, gs, opts.Rules, solution)
+			}
+		})
+	}
+}
+
+func TestSolveCheckmate_RetrievedJokerNeedsNewMeld(t *testing.T) {
+	// R6 と入れ替えたジョーカーを元のランの端に付け直すことはできない
+	board := Board{Melds: []Meld{
+		{R3, R4, R5, JK},
+		{B9, Y9, K9, R9},
+	}}
+	hand := Hand{Tiles: []Tile{R6}}
+	gs := &GameState{Board: board, Hand: hand, Opened: true}
+
+	if got, solution := SolveCheckmateWithOptions(gs, DefaultOptions()); got {
+		t.Errorf("Expected no checkmate, got %v", solution)
+	}
+
+	opts := DefaultOptions()
+	opts.Rules.FreeJokers = true
+	got, solution := SolveCheckmateWithOptions(gs, opts)
+	if !got {
+		t.Fatal("Expected checkmate with free jokers, but got none")
+	}
+	checkSolution(t, gs, opts.Rules, solution)
+}
+
+func TestSolveCheckmate_BoardJokerWithDuplicates(t *testing.T) {
+	// 同じランが2つある場でも、ジョーカーと関係のないコピーは区別せずに探索する。
+	// コピーを元のメルドごとに区別すると、ランの分け方ごとに探索が2倍ずつ増える
+	run := Meld{R1, R2, R3, R4, R5, R6, R7, R8, R9, R10}
+	board := Board{Melds: []Meld{run, run, {B1, B2, B3, B4, B5, B6, B7, B8, B9, B10, B11, B12, JK}}}
+
+	tests := []struct {
+		name string
+		hand []Tile
+		want bool
+	}{
+		{"joker has nowhere to go", []Tile{B13}, false},
+		{"joker moves to a new group", []Tile{B13, R11, Y11, K11}, true},
+	}
+
+	for _, tt := range tests {
+		for _, engine := range []Engine{EngineBacktrack, EngineDLX} {
+			t.Run(fmt.Sprintf("%s/%s", tt.name, engine), func(t *testing.T) {
+				var stats Stats
+				gs := &GameState{Board: board, Hand: Hand{Tiles: tt.hand}, Opened: true}
+				opts := DefaultOptions()
+				opts.Engine = engine
+				opts.Stats = &stats
+
+				got, solution := SolveCheckmateWithOptions(gs, opts)
+				if got != tt.want {
+					t.Fatalf("SolveCheckmateWithOptions() = %v, want %v (solution %v)", got, tt.want, solution)
+				}
+				if got {
+					checkSolution(t, gs, opts.Rules, solution)
+				}
+				if stats.Nodes > 10000 {
+					t.Errorf("Stats.Nodes = %d, want at most 10000", stats.Nodes)
+				}
+			})
+		}
+	}
+}
+
+func TestMeld_JokerTiles(t *testing.T) {
+	tests := []struct {
+		name string
+		meld Meld
+		kind MeldKind
+		want []Tile
+	}{
+		{"run middle", Meld{R4, JK, R6}, MeldRun, []Tile{R5}},
+		{"run ends", Meld{JK, B5, B6, JK}, MeldRun, []Tile{B4, B7}},
+		{"unordered run", Meld{JK, R3, R2}, MeldRun, []Tile{R4}},
+		{"group", Meld{R5, JK, B5, JK}, MeldGroup, []Tile{Y5, K5}},
+		{"no joker", Meld{R7, B7, Y7}, MeldGroup, nil},
+		{"invalid", Meld{R1, B2, JK}, MeldInvalid, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.meld.Kind(); got != tt.kind {
+				t.Errorf("%s.Kind() = %s, want %s", tt.meld, got, tt.kind)
+			}
+			got := tt.meld.JokerTiles()
+			if len(got) != len(tt.want) {
+				t.Fatalf("%s.JokerTiles() = %v, want %v", tt.meld, got, tt.want)
+			}
+			for k := range got {
+				if got[k] != tt.want[k] {
+					t.Errorf("%s.JokerTiles() = %v, want %v", tt.meld, got, tt.want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,315 @@
+package rummikub
+
+import (
+	"context"
+	"slices"
+)
+
+// MaxPlayResult は手札をできるだけ多く出したときの結果
+type MaxPlayResult struct {
+	Board  Board // 出した後の場
+	Hand   Hand  // 手札に残ったタイル
+	Played int   // 出した手札の枚数
+}
+
+// SolveMaxPlay は場のタイルをすべて有効なメルドに収めたまま、
+// 出せる手札の枚数が最大になる手を探す。
+// 場のタイルだけでメルドを組めない場合は false を返す。
+func SolveMaxPlay(board Board, hand Hand) (MaxPlayResult, bool) {
+	gs := &GameState{Board: board, Hand: hand, Opened: true}
+	return SolveMaxPlayWithOptions(gs, DefaultOptions())
+}
+
+// SolveMaxPlayWithOptions はゲームの状態と探索設定を指定して SolveMaxPlay を行う。
+// 初手前で条件を満たす出し方がなければ、何も出さない結果を返す。
+func SolveMaxPlayWithOptions(gs *GameState, opts Options) (MaxPlayResult, bool) {
+	result, ok, _ := SolveMaxPlayContext(context.Background(), gs, opts)
+	return result, ok
+}
+
+// SolveMaxPlayContext は ctx の期限とキャンセルに従って SolveMaxPlayWithOptions を行う。
+// 打ち切ったときは ctx.Err() を返す。
+func SolveMaxPlayContext(ctx context.Context, gs *GameState, opts Options) (MaxPlayResult, bool, error) {
+	opts.Rules = opts.Rules.withDefaults()
+	if err := ctx.Err(); err != nil {
+		return MaxPlayResult{}, false, err
+	}
+	allTiles := collectTiles(gs.Board, gs.Hand)
+	boardCount := len(allTiles) - len(gs.Hand.Tiles)
+
+	check := newRuleCheck(gs, opts.Rules, allTiles)
+	var accept acceptFunc
+	var state func([]Tile) memoKey
+	if check != nil {
+		accept, state = check.accept, check.state
+	}
+	key := copyKey(gs.Board, allTiles, accept != nil)
+	mon := newMonitor(ctx)
+	mon.memoSize = opts.memoSize()
+	mon.memoRules = &memoRules{kind: key, state: state}
+
+	var solution [][]Tile
+	if !gs.Opened && !opts.Rules.OpeningManipulation {
+		// 初手前は場に触れず、手札だけで新しいメルドを作る。
+		// 判定には findCheckmate と同じく、動かさない場のメルドも含めた盤面を渡す
+		handTiles, fixed := coverTiles(gs, opts.Rules, allTiles)
+		for _, meld := range fixed {
+			if !Meld(meld).IsValidWith(opts.Rules) {
+				return MaxPlayResult{}, false, nil
+			}
+		}
+		handAccept := accept
+		if accept != nil {
+			handAccept = func(solution [][]Tile) bool { return accept(append(slices.Clip(fixed), solution...)) }
+		}
+		candidates := buildCandidateInfos(handTiles, check.allowed(GenerateAllCandidatesWithRules(handTiles, opts.Rules)))
+		handSolution, _ := maxCover(handTiles, candidates, make([]bool, len(handTiles)), copyOrder(handTiles, key), handAccept, mon)
+		if mon.err != nil {
+			return MaxPlayResult{}, false, mon.err
+		}
+		solution = append(fixed, handSolution...)
+	} else {
+		// 場のタイルは必ず置き、手札は置かなくてもよい
+		required := make([]bool, len(allTiles))
+		for i := 0; i < boardCount; i++ {
+			required[i] = true
+		}
+
+		candidates := buildCandidateInfos(allTiles, check.allowed(GenerateAllCandidatesWithRules(allTiles, opts.Rules)))
+		var ok bool
+		solution, ok = maxCover(allTiles, candidates, required, copyOrder(allTiles, key), accept, mon)
+		if mon.err != nil {
+			return MaxPlayResult{}, false, mon.err
+		}
+		if !ok {
+			return MaxPlayResult{}, false, nil
+		}
+		if accept == nil {
+			key = Tile.Notation
+		}
+		solution = preferOrigins(solution, gs.Board, allTiles, key)
+	}
+
+	result := MaxPlayResult{}
+	placed := make(map[TileID]bool)
+	for _, candidate := range arrangeSolution(solution, gs.Board, opts.Rules) {
+		result.Board.Melds = append(result.Board.Melds, Meld(candidate))
+		for _, tile := range candidate {
+			placed[tile.ID] = true
+		}
+	}
+	for _, tile := range allTiles[boardCount:] {
+		if placed[tile.ID] {
+			result.Played++
+		} else {
+			result.Hand.Tiles = append(result.Hand.Tiles, tile)
+		}
+	}
+	return result, true, nil
+}
+
+// maxCover は required なタイルをすべて覆い、それ以外のタイルを
+// できるだけ多く覆う候補の組み合わせを探す。
+// accept が解を拒否した場合、その組み合わせは採用しない。
+// copies は copyOrder の結果で、required と任意のタイルは別のキーでなければならない。
+// mon.memoRules があれば、その kind を copies と同じキーとして、部分局面ごとに置ける任意タイルの数の上限を覚える。
+// mon が探索を打ち切ったときは解なしとして返る。
+func maxCover(tiles []Tile, candidates []candidateInfo, required []bool, copies []int, accept acceptFunc, mon *monitor) ([][]Tile, bool) {
+	optional := 0
+	for _, r := range required {
+		if !r {
+			optional++
+		}
+	}
+
+	s := &maxCoverSearch{
+		candidates: candidates,
+		byTile:     candidatesByTile(len(required), candidates),
+		required:   required,
+		copies:     copies,
+		later:      slices.Repeat([]int{-1}, len(required)),
+		accept:     accept,
+		mon:        mon,
+		used:       newTileSet(len(required)),
+		skipped:    newTileSet(len(required)),
+	}
+	for i, prev := range copies {
+		if prev >= 0 {
+			s.later[prev] = i
+		}
+	}
+	if mon != nil && mon.memoRules != nil {
+		if s.hashes = mon.memoHashes(tiles, candidates, accept); s.hashes != nil {
+			s.memo = newMemoTable[int](mon.memoSize)
+			s.tileHashes = tileHashes(tiles, mon.memoRules.kind)
+		}
+	}
+	// 置く数を多い方から1つずつ下げ、最初に見つかった解を返す。
+	// 表に覚えた上限は目標によらず正しいので、目標を下げても使い回せる
+	for target := optional; target >= 0 && !mon.stopped(); target-- {
+		s.target = target
+		if s.search(0) {
+			return s.solution, true
+		}
+	}
+	return nil, false
+}
+
+// maxCoverSearch は maxCover の探索状態
+type maxCoverSearch struct {
+	candidates []candidateInfo
+	byTile     [][]int
+	required   []bool
+	copies     []int
+	later      []int // 各タイルについて、同じキーのタイルのうち1つ後のタイルの位置。なければ -1
+	accept     acceptFunc
+	mon        *monitor
+	used       tileSet // メルドに使われたタイル
+	skipped    tileSet // 手札に残すと決めたタイル
+	target     int     // 覆いたい任意タイルの数
+
+	// 部分局面は決めたタイル（使ったか残したか）のキーごとの枚数と判定の状態で表し、
+	// そこからさらに置ける任意タイルの数の上限を覚える。nil なら覚えない
+	memo       *memoTable[int]
+	hashes     []memoKey // 候補ごとのハッシュ
+	tileHashes []memoKey // 手札に残すタイルごとのハッシュ
+	key        memoKey   // 決めたタイルと選んだ候補のハッシュ
+
+	current  []int // 現在選んでいる候補
+	solution [][]Tile
+}
+
+// search は target 枚の任意タイルを覆う解を探す。placed はこれまでに覆った任意タイルの数。
+// 未決定のタイルのうち選択肢の最も少ないものについて、候補で覆うか手札に残すかを分岐する。
+func (s *maxCoverSearch) search(placed int) bool {
+	if !s.mon.visit() {
+		return false
+	}
+
+	// 同じ部分局面から置ける数の上限が分かっていれば、それで目標に届くかを確かめる
+	if s.memo != nil {
+		gain, hit := s.memo.get(s.key)
+		s.mon.probe(hit)
+		if hit && placed+gain < s.target {
+			return false
+		}
+	}
+
+	first, reachable := s.choose()
+	if first == -2 || placed+reachable < s.target {
+		return s.fail(placed)
+	}
+	if first == -1 {
+		var solution [][]Tile
+		for _, c := range s.current {
+			solution = append(solution, s.candidates[c].tiles)
+		}
+		if s.accept != nil && !s.accept(solution) {
+			return false
+		}
+		s.solution = solution
+		return true
+	}
+	// 同じキーのコピーは小さい位置のものから使うので、まだ決めていない最初のコピーで分岐する
+	for prev := s.copies[first]; prev >= 0 && !s.used.has(prev) && !s.skipped.has(prev); prev = s.copies[prev] {
+		first = prev
+	}
+
+	for _, c := range s.byTile[first] {
+		candidate := &s.candidates[c]
+		if !s.used.disjoint(candidate.mask) || !s.skipped.disjoint(candidate.mask) || !lowestCopies(*candidate, first, s.copies, s.used) {
+			continue
+		}
+
+		gained := 0
+		for _, idx := range candidate.indices {
+			if !s.required[idx] {
+				gained++
+			}
+		}
+		s.used.union(candidate.mask)
+		s.current = append(s.current, c)
+		if s.memo != nil {
+			s.key = s.key.plus(s.hashes[c])
+		}
+
+		done := s.search(placed + gained)
+
+		if s.memo != nil {
+			s.key = s.key.minus(s.hashes[c])
+		}
+		s.current = s.current[:len(s.current)-1]
+		s.used.subtract(candidate.mask)
+		s.mon.undo()
+		if done {
+			return true
+		}
+	}
+
+	// 任意タイルは手札に残すこともできる。first を残すなら、まだ決めていない同じキーのコピーもすべて残す
+	if !s.required[first] {
+		var kept []int
+		for x := first; x >= 0; x = s.later[x] {
+			if !s.used.has(x) && !s.skipped.has(x) {
+				kept = append(kept, x)
+				s.skipped.add(x)
+				if s.memo != nil {
+					s.key = s.key.plus(s.tileHashes[x])
+				}
+			}
+		}
+		done := s.search(placed)
+		for _, x := range kept {
+			s.skipped.remove(x)
+			if s.memo != nil {
+				s.key = s.key.minus(s.tileHashes[x])
+			}
+		}
+		if done {
+			return true
+		}
+	}
+	return s.fail(placed)
+}
+
+// fail は今の部分局面から目標に届かなかったことを表に覚えて false を返す。
+// ここから置ける任意タイルは target-placed 枚より少ない
+func (s *maxCoverSearch) fail(placed int) bool {
+	if s.memo != nil && !s.mon.stopped() {
+		s.memo.put(s.key, s.target-placed-1)
+	}
+	return false
+}
+
+// choose は未決定のタイルのうち、使える候補の最も少ないものを返す。任意タイルは手札に残す分を1つ足して比べる。
+// 候補のない required なタイルがあれば -2、分岐するタイルがなければ -1 を返す。
+// あわせて、使える候補が残っている未決定の任意タイルの数を返す。
+func (s *maxCoverSearch) choose() (first, reachable int) {
+	first = -1
+	fewest := 0
+	for i, r := range s.required {
+		if s.used.has(i) || s.skipped.has(i) {
+			continue
+		}
+		n := 0
+		for _, c := range s.byTile[i] {
+			if s.used.disjoint(s.candidates[c].mask) && s.skipped.disjoint(s.candidates[c].mask) {
+				n++
+			}
+		}
+		switch {
+		case r && n == 0:
+			return -2, reachable
+		case n == 0:
+			// 置けない任意タイルは手札に残るしかない
+			continue
+		case !r:
+			reachable++
+			n++
+		}
+		if first == -1 || n < fewest {
+			first, fewest = i, n
+		}
+	}
+	return first, reachable
+}
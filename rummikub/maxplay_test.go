@@ -0,0 +1,118 @@
+package rummikub
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"testing"
+)
+
+func TestSolveMaxPlay_PartialPlay(t *testing.T) {
+	board := Board{Melds: []Meld{
+		{R1, R2, R3},
+	}}
+	hand := Hand{Tiles: []Tile{B5, B6, B7, Y11}}
+
+	result, ok := SolveMaxPlay(board, hand)
+	if !ok {
+		t.Fatal("Expected board to be coverable")
+	}
+	if result.Played != 3 {
+		t.Errorf("Expected 3 tiles played, got %d", result.Played)
+	}
+	if len(result.Hand.Tiles) != 1 || result.Hand.Tiles[0].Color != Yellow || result.Hand.Tiles[0].Number != 11 {
+		t.Errorf("Expected Y11 to remain in hand, got %s", result.Hand.String())
+	}
+	if len(result.Board.Melds) != 2 {
+		t.Errorf("Expected 2 melds on board, got %d", len(result.Board.Melds))
+	}
+	for _, meld := range result.Board.Melds {
+		if !meld.IsValid() {
+			t.Errorf("Invalid meld in result: %s", meld)
+		}
+	}
+}
+
+func TestSolveMaxPlay_UsesBoardTiles(t *testing.T) {
+	board := Board{Melds: []Meld{
+		{R4, R5, R6, R7},
+	}}
+	hand := Hand{Tiles: []Tile{B7, Y7, K1}}
+
+	result, ok := SolveMaxPlay(board, hand)
+	if !ok {
+		t.Fatal("Expected board to be coverable")
+	}
+	if result.Played != 2 {
+		t.Errorf("Expected 2 tiles played, got %d", result.Played)
+	}
+}
+
+func TestSolveMaxPlay_Checkmate(t *testing.T) {
+	board := Board{Melds: []Meld{
+		{R1, R2, R3},
+		{R7, B7, Y7},
+	}}
+	hand := Hand{Tiles: []Tile{B5, B6, B7, JK}}
+
+	result, ok := SolveMaxPlay(board, hand)
+	if !ok {
+		t.Fatal("Expected board to be coverable")
+	}
+	if result.Played != 4 || len(result.Hand.Tiles) != 0 {
+		t.Errorf("Expected all 4 tiles played, got %d (left: %s)", result.Played, result.Hand.String())
+	}
+}
+
+func TestSolveMaxPlay_NothingPlayable(t *testing.T) {
+	board := Board{Melds: []Meld{
+		{R1, R2, R3},
+	}}
+	hand := Hand{Tiles: []Tile{B9, Y12}}
+
+	result, ok := SolveMaxPlay(board, hand)
+	if !ok {
+		t.Fatal("Expected board to be coverable")
+	}
+	if result.Played != 0 || len(result.Hand.Tiles) != 2 {
+		t.Errorf("Expected nothing played, got %d", result.Played)
+	}
+	if len(result.Board.Melds) != 1 {
+		t.Errorf("Expected board unchanged, got %d melds", len(result.Board.Melds))
+	}
+}
+
+func TestSolveMaxPlay_InvalidBoard(t *testing.T) {
+	board := Board{Melds: []Meld{
+		{R1, B2},
+	}}
+	hand := Hand{Tiles: []Tile{Y5}}
+
+	if _, ok := SolveMaxPlay(board, hand); ok {
+		t.Error("Expected invalid board to be rejected")
+	}
+}
+
+func BenchmarkSolveMaxPlay(b *testing.B) {
+	// 50枚の場に、メルドにならない13枚の手札を足す。場を崩して手札をどこまで付けられるかの探索になる
+	colors := []Color{Red, Blue, Yellow, Black}
+	for _, seed := range []uint64{1, 2, 5} {
+		board, _ := generatePosition(seed, 50, 0)
+		rng := rand.New(rand.NewPCG(seed, 99))
+		var hand Hand
+		for len(hand.Tiles) < 13 {
+			hand.Tiles = append(hand.Tiles, NewTile(colors[rng.IntN(4)], TileNumber(1+rng.IntN(13))))
+		}
+		gs := &GameState{Board: board, Hand: hand, Opened: true}
+
+		for _, memo := range []int{-1, 0} {
+			b.Run(fmt.Sprintf("Generated50+13/seed=%d/memo=%d", seed, memo), func(b *testing.B) {
+				b.ReportAllocs()
+				opts := DefaultOptions()
+				opts.Memo = memo
+				for b.Loop() {
+					SolveMaxPlayWithOptions(gs, opts)
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,261 @@
+package rummikub
+
+import "fmt"
+
+type Meld []Tile
+
+// isValidSet は同じ数字で異なる色の組み合わせかチェック
+func (m Meld) isValidSet(r Rules) bool {
+	if len(m) < r.MinMeldSize || len(m) > r.colorCount() {
+		return false
+	}
+
+	colors := make(map[Color]bool)
+	var number TileNumber = -1
+
+	for _, tile := range m {
+		if tile.IsJoker {
+			continue
+		}
+		if !r.validTile(tile) {
+			return false
+		}
+		if number == -1 {
+			number = tile.Number
+		} else if tile.Number != number {
+			return false
+		}
+		if colors[tile.Color] {
+			return false
+		}
+		colors[tile.Color] = true
+	}
+	return true
+}
+
+// isValidRun は同じ色で連続する数字の組み合わせかチェック
+func (m Meld) isValidRun(r Rules) bool {
+	if len(m) < r.MinMeldSize {
+		return false
+	}
+
+	// 色が統一されているかチェック
+	var runColor Color
+	first := true
+	for _, tile := range m {
+		if tile.IsJoker {
+			continue
+		}
+		if !r.validTile(tile) {
+			return false
+		}
+		if first {
+			runColor = tile.Color
+			first = false
+		} else if tile.Color != runColor {
+			return false
+		}
+	}
+
+	// 連続性チェック（ジョーカーで埋められるか）
+	return len(m.runStarts(r)) > 0
+}
+
+// runStarts はランとして並べられる開始の数字をすべて返す。
+// 開始の数字から len(m) 個の連続する数字（折り返しありなら13→1と続く）に、
+// ジョーカー以外のタイルの数字がちょうど1回ずつ含まれるものを探す。
+func (m Meld) runStarts(r Rules) []TileNumber {
+	count := r.numberCount()
+	if len(m) > count {
+		return nil
+	}
+
+	numbers := make(map[TileNumber]bool)
+	for _, tile := range m {
+		if tile.IsJoker {
+			continue
+		}
+		if numbers[tile.Number] {
+			return nil // 重複
+		}
+		numbers[tile.Number] = true
+	}
+
+	last := r.MaxNumber - TileNumber(len(m)) + 1
+	if r.WrapRuns {
+		last = r.MaxNumber
+	}
+
+	var starts []TileNumber
+	for start := r.MinNumber; start <= last; start++ {
+		covered := 0
+		for k := 0; k < len(m); k++ {
+			if numbers[r.runNumber(start, k)] {
+				covered++
+			}
+		}
+		if covered == len(numbers) {
+			starts = append(starts, start)
+		}
+	}
+	return starts
+}
+
+// MeldKind はメルドの種類
+type MeldKind int
+
+const (
+	// MeldInvalid は有効でないメルド
+	MeldInvalid MeldKind = iota
+	// MeldRun は同じ色の連続する数字のメルド
+	MeldRun
+	// MeldGroup は同じ数字の異なる色のメルド
+	MeldGroup
+)
+
+func (k MeldKind) String() string {
+	switch k {
+	case MeldRun:
+		return "run"
+	case MeldGroup:
+		return "group"
+	default:
+		return "invalid"
+	}
+}
+
+// Kind はメルドの種類を返す。ランとしてもグループとしても有効ならランとする。
+func (m Meld) Kind() MeldKind {
+	return m.KindWith(DefaultRules())
+}
+
+// KindWith は指定したルールでのメルドの種類を返す
+func (m Meld) KindWith(r Rules) MeldKind {
+	r = r.withDefaults()
+	switch {
+	case m.isValidRun(r):
+		return MeldRun
+	case m.isValidSet(r):
+		return MeldGroup
+	default:
+		return MeldInvalid
+	}
+}
+
+func (m Meld) IsValid() bool {
+	return m.IsValidWith(DefaultRules())
+}
+
+// IsValidWith は指定したルールでメルドが有効かを返す
+func (m Meld) IsValidWith(r Rules) bool {
+	r = r.withDefaults()
+	return m.isValidSet(r) || m.isValidRun(r)
+}
+
+// Points はメルドの点数（数字の合計）を返す。
+// ジョーカーは代わりをしているタイルの数字として数え、
+// 解釈が複数ある場合は最も点数が高いものを採用する。無効なメルドは0点。
+func (m Meld) Points() int {
+	return m.PointsWith(DefaultRules())
+}
+
+// PointsWith は指定したルールでのメルドの点数を返す
+func (m Meld) PointsWith(r Rules) int {
+	r = r.withDefaults()
+	points := 0
+	if m.isValidSet(r) {
+		for _, tile := range m {
+			if !tile.IsJoker {
+				points = int(tile.Number) * len(m)
+				break
+			}
+		}
+	}
+	if m.isValidRun(r) {
+		if p := m.runPoints(r); p > points {
+			points = p
+		}
+	}
+	return points
+}
+
+// runPoints はランとして並べたときの最大点数を返す
+func (m Meld) runPoints(r Rules) int {
+	start, ok := m.runStart(r)
+	if !ok {
+		return 0
+	}
+	return r.runStartPoints(start, len(m))
+}
+
+// runStart は並び順によらずランとして解釈するときの開始の数字を返す。
+// 端のジョーカーのように開始の数字が決まらなければ、点数が最も大きくなるものを選ぶ。
+// PointsWith と、並んでいないランの Resolve はこの開始の数字を使う。
+func (m Meld) runStart(r Rules) (TileNumber, bool) {
+	starts := m.runStarts(r)
+	if len(starts) == 0 {
+		return 0, false
+	}
+	best := starts[0]
+	for _, start := range starts[1:] {
+		if r.runStartPoints(start, len(m)) > r.runStartPoints(best, len(m)) {
+			best = start
+		}
+	}
+	return best, true
+}
+
+func (m Meld) String() string {
+	result := "["
+	for i, tile := range m {
+		if i > 0 {
+			result += " "
+		}
+		result += tile.String()
+	}
+	return result + "]"
+}
+
+// Board は場に出ているすべてのMeld
+type Board struct {
+	Melds []Meld
+}
+
+func (b *Board) String() string {
+	result := "Board:\n"
+	for i, meld := range b.Melds {
+		result += fmt.Sprintf("  %d: %s\n", i+1, meld.String())
+	}
+	return result
+}
+
+// Hand はプレイヤーの手札
+type Hand struct {
+	Tiles []Tile
+}
+
+func (h *Hand) String() string {
+	result := "Hand: "
+	for i, tile := range h.Tiles {
+		if i > 0 {
+			result += " "
+		}
+		result += tile.String()
+	}
+	return result
+}
+
+// GameState はゲームの状態を表す
+type GameState struct {
+	Board  Board
+	Hand   Hand
+	Opened bool // 初手（30点ルール）を済ませているか
+}
+
+func (g *GameState) String() string {
+	result := g.Board.String() + g.Hand.String()
+	if !g.Opened {
+		result += "\n(初手前)"
+	}
+	return result
+}
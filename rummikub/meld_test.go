@@ -0,0 +1,29 @@
+package rummikub
+
+import "testing"
+
+func TestMeld_IsValid(t *testing.T) {
+	tests := []struct {
+		name string
+		meld Meld
+		want bool
+	}{
+		{"run", Meld{R1, R2, R3}, true},
+		{"group", Meld{R7, B7, Y7}, true},
+		{"four color group", Meld{R7, B7, Y7, K7}, true},
+		{"run with joker", Meld{R1, JK, R3}, true},
+		{"group with joker", Meld{R7, JK, Y7}, true},
+		{"too short", Meld{R1, R2}, false},
+		{"mixed color run", Meld{R1, B2, R3}, false},
+		{"duplicate color group", Meld{R7, R7, Y7}, false},
+		{"gap without joker", Meld{R1, R2, R4}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.meld.IsValid(); got != tt.want {
+				t.Errorf("%s.IsValid() = %v, want %v", tt.meld, got, tt.want)
+			}
+		})
+	}
+}
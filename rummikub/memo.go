@@ -0,0 +1,176 @@
+package rummikub
+
+// DefaultMemoSize は失敗した部分局面を覚える表の既定の大きさ（エントリ数）。1エントリは16バイト
+const DefaultMemoSize = 1 << 18
+
+// memoKey は使ったタイルの多重集合のハッシュ。独立な2つの64ビットのハッシュを並べる
+type memoKey [2]uint64
+
+// plus はタイルを加えたときのハッシュを返す
+func (k memoKey) plus(o memoKey) memoKey {
+	return memoKey{k[0] + o[0], k[1] + o[1]}
+}
+
+// minus はタイルを除いたときのハッシュを返す
+func (k memoKey) minus(o memoKey) memoKey {
+	return memoKey{k[0] - o[0], k[1] - o[1]}
+}
+
+// times は k を n 倍した値を返す
+func (k memoKey) times(n int) memoKey {
+	return memoKey{k[0] * uint64(n), k[1] * uint64(n)}
+}
+
+// failMemo は解のなかった部分局面を覚える置換表。
+// 部分局面は使ったタイルの種類ごとの枚数で表し、同じ種類のコピー同士やジョーカー同士は区別しない。
+// キーは種類ごとの乱数の和なので、候補を選ぶたびに候補の乱数の和を足し引きするだけで求まる。
+type failMemo = memoTable[struct{}]
+
+// newFailMemo は最大 size エントリの表を返す。size が0以下なら nil（覚えない）
+func newFailMemo(size int) *failMemo {
+	return newMemoTable[struct{}](size)
+}
+
+// memoTable は部分局面ごとに値を覚える置換表。
+// 表は直接写像で、同じ位置に入る部分局面は新しいもので上書きする。
+// 小さく作り、埋まってきたら size まで倍に広げる。
+type memoTable[V any] struct {
+	slots  []memoKey
+	values []V
+	count  int // 覚えている部分局面の数
+	size   int // 表の大きさの上限
+}
+
+// newMemoTable は最大 size エントリの表を返す。size が0以下なら nil（覚えない）
+func newMemoTable[V any](size int) *memoTable[V] {
+	if size <= 0 {
+		return nil
+	}
+	n := min(size, 256)
+	return &memoTable[V]{slots: make([]memoKey, n), values: make([]V, n), size: size}
+}
+
+// contains は部分局面 k を覚えているかを返す
+func (m *memoTable[V]) contains(k memoKey) bool {
+	_, ok := m.get(k)
+	return ok
+}
+
+// add は部分局面 k を覚える
+func (m *memoTable[V]) add(k memoKey) {
+	var zero V
+	m.put(k, zero)
+}
+
+// get は部分局面 k について覚えた値を返す
+func (m *memoTable[V]) get(k memoKey) (V, bool) {
+	i := k[0] % uint64(len(m.slots))
+	if k == (memoKey{}) || m.slots[i] != k {
+		var zero V
+		return zero, false
+	}
+	return m.values[i], true
+}
+
+// put は部分局面 k の値 v を覚える
+func (m *memoTable[V]) put(k memoKey, v V) {
+	if k == (memoKey{}) {
+		return // 何も使っていない局面は空きと区別できないので覚えない
+	}
+	if m.count*2 >= len(m.slots) && len(m.slots) < m.size {
+		slots, values := m.slots, m.values
+		m.slots = make([]memoKey, min(len(slots)*2, m.size))
+		m.values = make([]V, len(m.slots))
+		m.count = 0
+		for i, key := range slots {
+			if key != (memoKey{}) {
+				m.store(key, values[i])
+			}
+		}
+	}
+	m.store(k, v)
+}
+
+// store は k と v を表に入れる
+func (m *memoTable[V]) store(k memoKey, v V) {
+	i := k[0] % uint64(len(m.slots))
+	if m.slots[i] == (memoKey{}) {
+		m.count++
+	}
+	m.slots[i], m.values[i] = k, v
+}
+
+// candidateHashes は各候補のタイルの種類ごとの乱数の和を返す
+func candidateHashes(tiles []Tile, candidates []candidateInfo, key func(Tile) string) []memoKey {
+	tileHash := tileHashes(tiles, key)
+	hashes := make([]memoKey, len(candidates))
+	for c, candidate := range candidates {
+		for _, idx := range candidate.indices {
+			hashes[c] = hashes[c].plus(tileHash[idx])
+		}
+	}
+	return hashes
+}
+
+// tileHashes は各タイルの種類ごとの乱数を返す。
+// key が同じタイルには同じ乱数を使う（乱数は固定の種から作るので実行のたびに同じ）。
+func tileHashes(tiles []Tile, key func(Tile) string) []memoKey {
+	state := uint64(0x9e3779b97f4a7c15)
+	kinds := make(map[string]memoKey)
+	hashes := make([]memoKey, len(tiles))
+	for i, tile := range tiles {
+		k := key(tile)
+		h, ok := kinds[k]
+		if !ok {
+			h = memoKey{splitmix64(&state), splitmix64(&state)}
+			kinds[k] = h
+		}
+		hashes[i] = h
+	}
+	return hashes
+}
+
+// memoRules は解の判定があるときに、解のなかった部分局面の表を使うための情報。
+// 判定の結果が、使ったタイルの kind ごとの枚数と、選んだ候補の state の和だけで決まるなら、
+// 部分局面をその2つで表しても、解があるかは変わらない。
+type memoRules struct {
+	kind  func(Tile) string    // 判定が見分けるタイルの種類
+	state func([]Tile) memoKey // 候補を選んだときに判定の状態に加える値。nil なら加えない
+}
+
+// hashes は各候補の、kind ごとの乱数の和に state を加えた値を返す
+func (m *memoRules) hashes(tiles []Tile, candidates []candidateInfo) []memoKey {
+	hashes := candidateHashes(tiles, candidates, m.kind)
+	if m.state != nil {
+		for c, candidate := range candidates {
+			hashes[c] = hashes[c].plus(m.state(candidate.tiles))
+		}
+	}
+	return hashes
+}
+
+// 判定の状態の乱数を作る種。判定ごとに別の種を使う
+const (
+	openingSalt = 1
+	jokerSalt   = 2
+)
+
+// stateHashes は判定の状態に使う n 個の乱数を返す。
+// タイルの種類の乱数とも、他の判定の乱数とも重ならないよう salt ごとに別の種から作る。
+func stateHashes(salt uint64, n int) []memoKey {
+	state := 0x243f6a8885a308d3 ^ salt<<32
+	hashes := make([]memoKey, n)
+	for i := range hashes {
+		hashes[i] = memoKey{splitmix64(&state), splitmix64(&state)}
+	}
+	return hashes
+}
+
+// splitmix64 は state を進めて64ビットの乱数を返す
+func splitmix64(state *uint64) uint64 {
+	*state += 0x9e3779b97f4a7c15
+	z := *state
+	z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+	z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+	return z ^ (z >> 31)
+}
@@ -0,0 +1,171 @@
+package rummikub
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+)
+
+func TestFailMemo(t *testing.T) {
+	m := newFailMemo(1000)
+	for i := uint64(1); i <= 5000; i++ {
+		m.add(memoKey{i, i * 7})
+	}
+	if len(m.slots) > 1000 {
+		t.Errorf("memo grew to %d slots, want at most 1000", len(m.slots))
+	}
+	if !m.contains(memoKey{5000, 5000 * 7}) {
+		t.Errorf("memo lost the latest key")
+	}
+	if m.contains(memoKey{5000, 1}) {
+		t.Errorf("memo matched a different key")
+	}
+	if m.contains(memoKey{}) {
+		t.Errorf("memo matched the empty key")
+	}
+	if newFailMemo(0) != nil {
+		t.Errorf("newFailMemo(0) should disable the memo")
+	}
+}
+
+func TestCandidateHashes(t *testing.T) {
+	// 同じ種類のコピーとジョーカーは区別しない
+	tiles := collectTiles(Board{}, Hand{Tiles: []Tile{R1, R1, R2, R3, JK, JK}})
+	candidates := buildCandidateInfos(tiles, [][]Tile{
+		{tiles[0], tiles[2], tiles[4]},
+		{tiles[1], tiles[2], tiles[5]},
+		{tiles[0], tiles[2], tiles[3]},
+	})
+	hashes := candidateHashes(tiles, candidates, Tile.Notation)
+	if hashes[0] != hashes[1] {
+		t.Errorf("copies hash differently: %v, %v", hashes[0], hashes[1])
+	}
+	if hashes[0] == hashes[2] {
+		t.Errorf("different tiles hash the same: %v", hashes[0])
+	}
+}
+
+func TestSolveCheckmate_Memo(t *testing.T) {
+	// 表を使っても使わなくても同じ結果になる。解のない局面も確かめる
+	for seed := uint64(1); seed <= 10; seed++ {
+		board, hand := generatePositionWithCopies(seed, 40, 2, 2)
+		for _, extra := range [][]Tile{nil, {R1, B13}} {
+			hand := Hand{Tiles: append(hand.Tiles[:len(hand.Tiles):len(hand.Tiles)], extra...)}
+			gs := &GameState{Board: board, Hand: hand, Opened: true}
+			for _, engine := range []Engine{EngineBacktrack, EngineDLX} {
+				opts := DefaultOptions()
+				opts.Engine = engine
+				opts.Memo = -1
+				want, _ := SolveCheckmateWithOptions(gs, opts)
+
+				var stats Stats
+				opts.Memo = 0
+				opts.Stats = &stats
+				got, solution := SolveCheckmateWithOptions(gs, opts)
+				if got != want {
+					t.Errorf("seed %d %v %s: with memo = %v, without = %v", seed, extra, engine, got, want)
+				}
+				if got {
+					checkSolutionTiles(t, board, hand, solution)
+				}
+				if stats.MemoHits > stats.MemoProbes {
+					t.Errorf("seed %d %v %s: Stats = %v", seed, extra, engine, stats)
+				}
+			}
+		}
+	}
+}
+
+func TestSolveCheckmate_MemoWithRules(t *testing.T) {
+	// 初手やジョーカーの判定があっても、表を使っても使わなくても同じ結果になる
+	for seed := uint64(1); seed <= 30; seed++ {
+		board, hand := generatePositionWithCopies(seed, 12, 1, 2)
+		// 場のタイルを1枚ジョーカーに替え、替えたタイルを手札に入れる
+		melds := slices.Clone(board.Melds)
+		meld := slices.Clone(melds[seed%uint64(len(melds))])
+		hand.Tiles = append(slices.Clip(hand.Tiles), meld[0])
+		meld[0] = JK
+		melds[seed%uint64(len(melds))] = meld
+		board = Board{Melds: melds}
+
+		for _, opened := range []bool{true, false} {
+			gs := &GameState{Board: board, Hand: hand, Opened: opened}
+			for _, engine := range []Engine{EngineBacktrack, EngineDLX} {
+				opts := DefaultOptions()
+				opts.Engine = engine
+				opts.Rules.OpeningManipulation = true
+				opts.Memo = -1
+				want, _ := SolveCheckmateWithOptions(gs, opts)
+
+				opts.Memo = 0
+				got, solution := SolveCheckmateWithOptions(gs, opts)
+				if got != want {
+					t.Errorf("seed %d opened %v %s: with memo = %v, without = %v", seed, opened, engine, got, want)
+				}
+				if got {
+					checkSolution(t, gs, opts.Rules, solution)
+				}
+			}
+		}
+	}
+}
+
+func TestSolveCheckmate_MemoHits(t *testing.T) {
+	// 最初の枝に解がなく、同じ残りのタイルに何度もたどり着く局面
+	board, hand := generatePosition(9, 60, 2)
+	hand.Tiles = append(hand.Tiles, R1, JK)
+	gs := &GameState{Board: board, Hand: hand, Opened: true}
+
+	for _, memo := range []int{-1, 0} {
+		var stats Stats
+		opts := DefaultOptions()
+		opts.Engine = EngineDLX
+		opts.Memo = memo
+		opts.Stats = &stats
+		got, solution := SolveCheckmateWithOptions(gs, opts)
+		if !got {
+			t.Fatalf("memo %d: no checkmate", memo)
+		}
+		checkSolutionTiles(t, board, hand, solution)
+		if hits := stats.MemoHits > 0; hits != (memo == 0) {
+			t.Errorf("memo %d: Stats = %v", memo, stats)
+		}
+	}
+}
+
+func BenchmarkSolveCheckmateMemo(b *testing.B) {
+	board, hand := generatePosition(60, 60, 2)
+	hardBoard, hardHand := generatePosition(9, 60, 2)
+	hardHand.Tiles = append(hardHand.Tiles, R1, JK)
+	positions := []testPosition{
+		{name: "Generated60", board: board, hand: hand},
+		{name: "Hard60", board: hardBoard, hand: hardHand},
+	}
+
+	catalogFor(DefaultRules().withDefaults())
+
+	for _, p := range positions {
+		for _, engine := range []Engine{EngineBacktrack, EngineDLX} {
+			for _, memo := range []int{-1, 0} {
+				// 表なしの backtrack は Hard60 で終わらない
+				if p.name == "Hard60" && engine == EngineBacktrack && memo < 0 {
+					continue
+				}
+				b.Run(fmt.Sprintf("%s/%s/memo=%d", p.name, engine, memo), func(b *testing.B) {
+					b.ReportAllocs()
+					var stats Stats
+					opts := DefaultOptions()
+					opts.Engine = engine
+					opts.Memo = memo
+					opts.Stats = &stats
+					gs := &GameState{Board: p.board, Hand: p.hand, Opened: true}
+					for b.Loop() {
+						SolveCheckmateWithOptions(gs, opts)
+					}
+					b.ReportMetric(float64(stats.Nodes), "nodes/op")
+					b.ReportMetric(stats.MemoHitRate(), "hit-rate")
+				})
+			}
+		}
+	}
+}
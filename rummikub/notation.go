@@ -0,0 +1,211 @@
+package rummikub
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// 局面の表記
+//
+//	position = board "/" hand
+//	board    = [ meld { "|" meld } ]
+//	meld     = tile { tile }
+//	hand     = { tile }
+//	tile     = color number | "JK"
+//	color    = "R" | "B" | "Y" | "K"
+//
+// タイルは空白（改行を含む）で区切る。"|" と "/" の前後の空白は省略できる。
+// 例: "R1 R2 R3 | R7 B7 Y7 / B5 B6 B7 JK"
+//
+// 解や組み替えた後の場は board だけで表す（ParseMelds）。
+
+// NotationError は局面の表記の誤り。Line と Column は1始まりの位置
+type NotationError struct {
+	Line, Column int
+	Msg          string
+}
+
+func (e *NotationError) Error() string {
+	return fmt.Sprintf("line %d, column %d: %s", e.Line, e.Column, e.Msg)
+}
+
+type tokenKind int
+
+const (
+	tokenTile tokenKind = iota
+	tokenMeldSep
+	tokenSideSep
+	tokenEOF
+)
+
+// token は表記を区切った1語
+type token struct {
+	kind         tokenKind
+	text         string
+	line, column int
+}
+
+// tokenize は表記をタイルと区切り記号に分ける。最後は tokenEOF で終わる。
+func tokenize(s string) []token {
+	var tokens []token
+	line, column := 1, 1
+	runes := []rune(s)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case r == '\n':
+			line, column = line+1, 1
+			i++
+		case unicode.IsSpace(r):
+			column++
+			i++
+		case r == '|' || r == '/':
+			kind := tokenMeldSep
+			if r == '/' {
+				kind = tokenSideSep
+			}
+			tokens = append(tokens, token{kind: kind, text: string(r), line: line, column: column})
+			column++
+			i++
+		default:
+			start := i
+			for i < len(runes) && !unicode.IsSpace(runes[i]) && runes[i] != '|' && runes[i] != '/' {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokenTile, text: string(runes[start:i]), line: line, column: column})
+			column += i - start
+		}
+	}
+	return append(tokens, token{kind: tokenEOF, line: line, column: column})
+}
+
+// ParsePosition は "R1 R2 R3 | R7 B7 Y7 / B5 B6 B7 JK" のような表記からゲームの状態を読み込む。
+// 初手は済んでいるものとして扱う。
+func ParsePosition(s string) (*GameState, error) {
+	return ParsePositionWithRules(s, DefaultRules())
+}
+
+// ParsePositionWithRules は指定したルールで ParsePosition を行う
+func ParsePositionWithRules(s string, r Rules) (*GameState, error) {
+	if err := r.Validate(); err != nil {
+		return nil, err
+	}
+	r = r.withDefaults()
+	gs := &GameState{Opened: true}
+
+	var meld Meld
+	inHand := false
+	for _, tok := range tokenize(s) {
+		errorAt := func(format string, args ...any) error {
+			return &NotationError{Line: tok.line, Column: tok.column, Msg: fmt.Sprintf(format, args...)}
+		}
+
+		switch tok.kind {
+		case tokenTile:
+			tile, err := ParseTileWithRules(tok.text, r)
+			if err != nil {
+				return nil, errorAt("%v", err)
+			}
+			if inHand {
+				gs.Hand.Tiles = append(gs.Hand.Tiles, tile)
+			} else {
+				meld = append(meld, tile)
+			}
+		case tokenMeldSep:
+			if inHand {
+				return nil, errorAt("unexpected | in hand")
+			}
+			if len(meld) == 0 {
+				return nil, errorAt("empty meld before |")
+			}
+			gs.Board.Melds = append(gs.Board.Melds, meld)
+			meld = nil
+		case tokenSideSep:
+			if inHand {
+				return nil, errorAt("unexpected second /")
+			}
+			if len(meld) == 0 && len(gs.Board.Melds) > 0 {
+				return nil, errorAt("empty meld before /")
+			}
+			if len(meld) > 0 {
+				gs.Board.Melds = append(gs.Board.Melds, meld)
+			}
+			inHand = true
+		case tokenEOF:
+			if !inHand {
+				return nil, errorAt("missing / between board and hand")
+			}
+		}
+	}
+
+	if err := gs.Validate(r); err != nil {
+		return nil, err
+	}
+	return gs, nil
+}
+
+// ParseMelds は "R1 R2 R3 R4 | B5 B6 B7" のようなメルドの並びの表記を読み込む。
+// 読み込むのはタイルの表記だけで、メルドが有効かは確かめない。
+func ParseMelds(s string) ([]Meld, error) {
+	return ParseMeldsWithRules(s, DefaultRules())
+}
+
+// ParseMeldsWithRules は指定したルールで ParseMelds を行う
+func ParseMeldsWithRules(s string, r Rules) ([]Meld, error) {
+	r = r.withDefaults()
+	var melds []Meld
+	var meld Meld
+	for _, tok := range tokenize(s) {
+		errorAt := func(format string, args ...any) error {
+			return &NotationError{Line: tok.line, Column: tok.column, Msg: fmt.Sprintf(format, args...)}
+		}
+
+		switch tok.kind {
+		case tokenTile:
+			tile, err := ParseTileWithRules(tok.text, r)
+			if err != nil {
+				return nil, errorAt("%v", err)
+			}
+			meld = append(meld, tile)
+		case tokenMeldSep:
+			if len(meld) == 0 {
+				return nil, errorAt("empty meld before |")
+			}
+			melds = append(melds, meld)
+			meld = nil
+		case tokenSideSep:
+			return nil, errorAt("unexpected / in melds")
+		case tokenEOF:
+			if len(meld) == 0 && len(melds) > 0 {
+				return nil, errorAt("empty meld at end")
+			}
+			if len(meld) > 0 {
+				melds = append(melds, meld)
+			}
+		}
+	}
+	return melds, nil
+}
+
+// FormatPosition はゲームの状態を ParsePosition で読み込める表記にする
+func FormatPosition(gs *GameState) string {
+	var b strings.Builder
+	for i, meld := range gs.Board.Melds {
+		if i > 0 {
+			b.WriteString(" | ")
+		}
+		for k, tile := range meld {
+			if k > 0 {
+				b.WriteByte(' ')
+			}
+			b.WriteString(tile.Notation())
+		}
+	}
+	b.WriteString(" /")
+	for _, tile := range gs.Hand.Tiles {
+		b.WriteByte(' ')
+		b.WriteString(tile.Notation())
+	}
+	return b.String()
+}
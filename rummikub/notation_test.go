@@ -0,0 +1,81 @@
+package rummikub
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParsePosition(t *testing.T) {
+	gs, err := ParsePosition("R1 R2 R3|R7 B7 Y7 /\n B5 B6 B7 JK")
+	if err != nil {
+		t.Fatalf("ParsePosition() error = %v", err)
+	}
+	if len(gs.Board.Melds) != 2 || len(gs.Board.Melds[1]) != 3 {
+		t.Errorf("Board = %v, want 2 melds", gs.Board)
+	}
+	if len(gs.Hand.Tiles) != 4 || !gs.Hand.Tiles[3].IsJoker {
+		t.Errorf("Hand = %v, want 4 tiles ending with JK", gs.Hand)
+	}
+	if got, want := FormatPosition(gs), "R1 R2 R3 | R7 B7 Y7 / B5 B6 B7 JK"; got != want {
+		t.Errorf("FormatPosition() = %q, want %q", got, want)
+	}
+
+	for _, s := range []string{"/ R1 R2 R3", "R1 R2 R3 /", "/"} {
+		if _, err := ParsePosition(s); err != nil {
+			t.Errorf("ParsePosition(%q) error = %v", s, err)
+		}
+	}
+}
+
+func TestParsePosition_Errors(t *testing.T) {
+	tests := []struct {
+		input        string
+		line, column int
+	}{
+		{"R1 R2 R3 | R7 X7 Y7 / B5", 1, 15},
+		{"R1 R2 R3 / B5 R14", 1, 15},
+		{"R1 R2 R3 | | R4 R5 R6 / B5", 1, 12},
+		{"R1 R2 R3 | / B5", 1, 12},
+		{"R1 R2 R3 / B5 | B6", 1, 15},
+		{"R1 R2 R3 / B5 / B6", 1, 15},
+		{"R1 R2 R3", 1, 9},
+		{"R1 R2 R3 |\n  R4 R5 R6 /\n B5 B6 X", 3, 8},
+	}
+
+	for _, tt := range tests {
+		_, err := ParsePosition(tt.input)
+		var nerr *NotationError
+		if !errors.As(err, &nerr) {
+			t.Errorf("ParsePosition(%q) error = %v, want NotationError", tt.input, err)
+			continue
+		}
+		if nerr.Line != tt.line || nerr.Column != tt.column {
+			t.Errorf("ParsePosition(%q) at %d:%d, want %d:%d", tt.input, nerr.Line, nerr.Column, tt.line, tt.column)
+		}
+	}
+}
+
+func TestParseMelds(t *testing.T) {
+	melds, err := ParseMelds("R1 R2 R3 R4 | B5 B6 B7 JK")
+	if err != nil {
+		t.Fatalf("ParseMelds() error = %v", err)
+	}
+	if len(melds) != 2 || len(melds[0]) != 4 || !melds[1][3].IsJoker {
+		t.Errorf("ParseMelds() = %v, want 2 melds", melds)
+	}
+
+	// 無効なメルドもそのまま読み込む
+	if melds, err := ParseMelds("R1 B2"); err != nil || len(melds) != 1 {
+		t.Errorf("ParseMelds(%q) = %v, %v", "R1 B2", melds, err)
+	}
+	if melds, err := ParseMelds(""); err != nil || len(melds) != 0 {
+		t.Errorf("ParseMelds(%q) = %v, %v", "", melds, err)
+	}
+
+	for _, s := range []string{"R1 R2 R3 / R4", "R1 R2 R3 |", "| R1 R2 R3", "R1 X2"} {
+		var nerr *NotationError
+		if _, err := ParseMelds(s); !errors.As(err, &nerr) {
+			t.Errorf("ParseMelds(%q) error = %v, want NotationError", s, err)
+		}
+	}
+}
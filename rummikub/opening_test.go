@@ -0,0 +1,162 @@
+package rummikub
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestMeld_Points(t *testing.T) {
+	tests := []struct {
+		meld Meld
+		want int
+	}{
+		{Meld{R10, R11, R12}, 33},
+		{Meld{R7, B7, Y7}, 21},
+		{Meld{R10, JK, R12}, 33},
+		{Meld{R12, R13, JK}, 36}, // JK=R11
+		{Meld{R1, R2, JK}, 6},    // JK=R3
+		{Meld{R5, B5, JK}, 15},
+		{Meld{R1, B2, Y3}, 0},
+	}
+
+	for _, tt := range tests {
+		if got := tt.meld.Points(); got != tt.want {
+			t.Errorf("%s.Points() = %d, want %d", tt.meld, got, tt.want)
+		}
+	}
+}
+
+func TestSolveCheckmateWithOptions_Opening(t *testing.T) {
+	board := Board{Melds: []Meld{
+		{R1, R2, R3},
+	}}
+
+	tests := []struct {
+		name         string
+		hand         []Tile
+		manipulation bool
+		want         bool
+	}{
+		{"enough points", []Tile{B10, B11, B12}, false, true},
+		{"not enough points", []Tile{B5, B6, B7}, false, false},
+		{"joker counts as its tile", []Tile{B10, JK, B12}, false, true},
+		{"two melds sum up", []Tile{B5, B6, B7, Y3, Y4, Y5}, false, true},
+		{"needs board without manipulation", []Tile{R4, B10, B11, B12}, false, false},
+		{"needs board with manipulation", []Tile{R4, B10, B11, B12}, true, true},
+		{"manipulation still needs points", []Tile{R4, B5, B6, B7}, true, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gs := &GameState{Board: board, Hand: Hand{Tiles: tt.hand}}
+			opts := DefaultOptions()
+			opts.Rules.OpeningManipulation = tt.manipulation
+
+			got, solution := SolveCheckmateWithOptions(gs, opts)
+			if got != tt.want {
+				t.Fatalf("SolveCheckmateWithOptions() = %v, want %v", got, tt.want)
+			}
+			if !got {
+				return
+			}
+			if !tt.manipulation && solution[0].String() != board.Melds[0].String() {
+				t.Errorf("Expected board meld untouched, got %s", solution[0])
+			}
+			checkSolution(t, gs, opts.Rules, solution)
+		})
+	}
+}
+
+func TestSolveMaxPlayWithOptions_Opening(t *testing.T) {
+	board := Board{Melds: []Meld{
+		{R1, R2, R3},
+	}}
+
+	// 30点に届かないので何も出せない
+	gs := &GameState{Board: board, Hand: Hand{Tiles: []Tile{B5, B6, B7, R4}}}
+	result, ok := SolveMaxPlayWithOptions(gs, DefaultOptions())
+	if !ok {
+		t.Fatal("Expected board to be coverable")
+	}
+	if result.Played != 0 {
+		t.Errorf("Expected nothing played before opening, got %d", result.Played)
+	}
+
+	// 30点以上のメルドだけ出し、場には触れない
+	gs = &GameState{Board: board, Hand: Hand{Tiles: []Tile{B10, B11, B12, R4}}}
+	result, ok = SolveMaxPlayWithOptions(gs, DefaultOptions())
+	if !ok {
+		t.Fatal("Expected board to be coverable")
+	}
+	if result.Played != 3 {
+		t.Errorf("Expected 3 tiles played, got %d", result.Played)
+	}
+
+	// 初手済みなら場に R4 を付けられる
+	gs.Opened = true
+	result, _ = SolveMaxPlayWithOptions(gs, DefaultOptions())
+	if result.Played != 4 {
+		t.Errorf("Expected 4 tiles played after opening, got %d", result.Played)
+	}
+}
+
+func TestSolveMaxPlayWithOptions_OpeningWithBoardJoker(t *testing.T) {
+	// 初手前は場のジョーカーに触れないので、ジョーカーの取り出しの判定で手を捨てない
+	board := Board{Melds: []Meld{{R1, R2, JK}}}
+	gs := &GameState{Board: board, Hand: Hand{Tiles: []Tile{B10, B11, B12, K1}}}
+
+	result, ok := SolveMaxPlayWithOptions(gs, DefaultOptions())
+	if !ok {
+		t.Fatal("Expected board to be coverable")
+	}
+	if result.Played != 3 {
+		t.Errorf("Expected 3 tiles played, got %d", result.Played)
+	}
+	if ok, _ := SolveCheckmateWithOptions(&GameState{Board: board, Hand: Hand{Tiles: []Tile{B10, B11, B12}}}, DefaultOptions()); !ok {
+		t.Error("Expected checkmate with B10-B12 before opening")
+	}
+}
+
+func TestSolveCheckmateWithOptions_OpeningArrangesJokers(t *testing.T) {
+	// [JK R9 R10] は JK=R11 として30点になる。解はその解釈どおりの並びで返し、
+	// 並び順どおりに解釈しても同じ点数になる
+	gs := &GameState{Board: Board{Melds: []Meld{{K1, K2, K3}}}, Hand: Hand{Tiles: []Tile{JK, R9, R10}}}
+	r := DefaultRules()
+	checked := 0
+	check := func(name string, melds []Meld) {
+		t.Helper()
+		for _, meld := range melds {
+			if !slices.ContainsFunc(meld, func(tile Tile) bool { return tile.IsJoker }) {
+				continue
+			}
+			checked++
+			res, err := meld.ResolveWith(r, true)
+			if err != nil {
+				t.Fatalf("%s: %s.ResolveWith(ordered) error = %v", name, meld, err)
+			}
+			points := 0
+			for _, tile := range res.Tiles {
+				points += int(tile.Number)
+			}
+			if points != meld.PointsWith(r) || points < r.InitialMeldPoints {
+				t.Errorf("%s: %s is %d points as laid, PointsWith = %d", name, res, points, meld.PointsWith(r))
+			}
+		}
+	}
+
+	ok, solution := SolveCheckmateWithOptions(gs, DefaultOptions())
+	if !ok {
+		t.Fatal("Expected checkmate with [R9 R10 JK] as the opening")
+	}
+	check("SolveCheckmateWithOptions", solution)
+	for melds := range SolutionsWithOptions(gs, DefaultOptions()) {
+		check("SolutionsWithOptions", melds)
+	}
+	_, minimal := SolveCheckmateMinimalDisruptionWithOptions(gs, DefaultOptions())
+	for _, m := range minimal {
+		check("SolveCheckmateMinimalDisruptionWithOptions", []Meld{m.Meld})
+	}
+	if checked < 3 {
+		t.Errorf("checked %d melds with a joker, want at least 3", checked)
+	}
+}
@@ -0,0 +1,186 @@
+package rummikub
+
+import (
+	"context"
+	"sync"
+)
+
+// parallelCover は最も候補の少ないタイルで探索を分け、分けた枝を workers 個のゴルーチンで探索する。
+// 枝の中は engine で探索する。どれかの枝で解が見つかれば他の枝を打ち切る。
+// deterministic が true なら、解のある枝のうち最も前の枝の解を返すので、
+// ゴルーチンの数や実行のたびに結果が変わらない（前の枝の探索が終わるまで待つ）。
+// accept は複数のゴルーチンから同時に呼ばれる。
+func parallelCover(engine Engine, tiles []Tile, infos []candidateInfo, copies []int, accept acceptFunc, mon *monitor, workers int, deterministic bool) ([][]Tile, bool) {
+	branch, ok := mostConstrained(len(tiles), infos)
+	if !ok {
+		mon.visit()
+		return nil, len(tiles) == 0 && (accept == nil || accept(nil))
+	}
+
+	var branches []candidateInfo
+	used := newTileSet(len(tiles))
+	for _, c := range infos {
+		if c.mask.has(branch) && lowestCopies(c, branch, copies, used) {
+			branches = append(branches, c)
+		}
+	}
+	if !mon.visit() {
+		return nil, false
+	}
+
+	type result struct {
+		solution [][]Tile
+		stats    Stats
+		err      error
+	}
+	results := make([]result, len(branches))
+	ctxs := make([]context.Context, len(branches))
+	cancels := make([]context.CancelFunc, len(branches))
+	for i := range branches {
+		ctxs[i], cancels[i] = context.WithCancel(mon.ctx)
+		defer cancels[i]()
+	}
+
+	var mu sync.Mutex
+	found := len(branches) // 解が見つかった最も前の枝
+	stopAfter := func(i int) {
+		mu.Lock()
+		defer mu.Unlock()
+		if i >= found {
+			return
+		}
+		found = i
+		for j := range branches {
+			if j > i || !deterministic {
+				cancels[j]()
+			}
+		}
+	}
+
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for range min(workers, len(branches)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				if err := ctxs[i].Err(); err != nil {
+					results[i].err = err
+					continue
+				}
+				solution, stats, err := solveBranch(ctxs[i], engine, tiles, infos, copies, accept, branches[i], mon.memoSize, mon.memoRules)
+				results[i] = result{solution, stats, err}
+				if err == nil && solution != nil {
+					stopAfter(i)
+				}
+			}
+		}()
+	}
+	for i := range branches {
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+
+	for _, r := range results {
+		mon.stats.Nodes += r.stats.Nodes
+		mon.stats.Backtracks += r.stats.Backtracks
+		mon.stats.MemoProbes += r.stats.MemoProbes
+		mon.stats.MemoHits += r.stats.MemoHits
+	}
+	// 途中で打ち切られた前の枝に解があったかもしれないなら、決まった解は返せない
+	if deterministic {
+		for _, r := range results[:found] {
+			if r.err != nil {
+				mon.err = r.err
+				return nil, false
+			}
+		}
+	}
+	if found < len(branches) {
+		return results[found].solution, true
+	}
+	mon.err = mon.ctx.Err()
+	return nil, false
+}
+
+// mostConstrained は覆う候補が最も少ないタイルを返す。タイルがなければ false
+func mostConstrained(n int, infos []candidateInfo) (int, bool) {
+	if n == 0 {
+		return 0, false
+	}
+	counts := make([]int, n)
+	for _, c := range infos {
+		for _, idx := range c.indices {
+			counts[idx]++
+		}
+	}
+	best := 0
+	for i, count := range counts {
+		if count < counts[best] {
+			best = i
+		}
+	}
+	return best, true
+}
+
+// solveBranch は候補 first を選んだ後の残りのタイルを engine で探索する。
+// 解が見つかれば first を先頭にした解を返し、見つからなければ nil を返す。
+// 解のなかった部分局面は枝ごとに最大 memoSize 個まで、memoRules に従って覚える。
+// 枝の中では first は変わらないので、first が判定の状態に加える値は部分局面の表し方に含めなくてよい。
+func solveBranch(ctx context.Context, engine Engine, tiles []Tile, infos []candidateInfo, copies []int, accept acceptFunc, first candidateInfo, memoSize int, memoRules *memoRules) ([][]Tile, Stats, error) {
+	// first のタイルを除き、コピーの順序は除いたタイルを飛ばしてつなぎ直す
+	removed := make([]bool, len(tiles))
+	for _, idx := range first.indices {
+		removed[idx] = true
+	}
+	index := make([]int, len(tiles))
+	var rest []Tile
+	for i, tile := range tiles {
+		index[i] = -1
+		if !removed[i] {
+			index[i] = len(rest)
+			rest = append(rest, tile)
+		}
+	}
+	var restCopies []int
+	if copies != nil {
+		for i := range tiles {
+			if removed[i] {
+				continue
+			}
+			x := copies[i]
+			for x >= 0 && removed[x] {
+				x = copies[x]
+			}
+			if x >= 0 {
+				x = index[x]
+			}
+			restCopies = append(restCopies, x)
+		}
+	}
+
+	withFirst := func(solution [][]Tile) [][]Tile {
+		return append([][]Tile{first.tiles}, solution...)
+	}
+	var restAccept acceptFunc
+	if accept != nil {
+		restAccept = func(solution [][]Tile) bool { return accept(withFirst(solution)) }
+	}
+
+	mon := newMonitor(ctx)
+	mon.memoSize = memoSize
+	mon.memoRules = memoRules
+	// first と重ならない候補だけが残りのタイルで作れる
+	var candidates [][]Tile
+	for _, c := range infos {
+		if c.mask.disjoint(first.mask) {
+			candidates = append(candidates, c.tiles)
+		}
+	}
+	solution, ok := solveCover(engine, rest, buildCandidateInfos(rest, candidates), restCopies, restAccept, mon)
+	if !ok {
+		return nil, mon.stats, mon.err
+	}
+	return withFirst(solution), mon.stats, nil
+}
@@ -0,0 +1,136 @@
+package rummikub
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestSolveCheckmate_Parallel(t *testing.T) {
+	for _, engine := range []Engine{EngineBacktrack, EngineDLX} {
+		for _, p := range testPositions {
+			t.Run(engine.String()+"/"+p.name, func(t *testing.T) {
+				opts := DefaultOptions()
+				opts.Engine = engine
+				opts.Workers = 4
+				gs := &GameState{Board: p.board, Hand: p.hand, Opened: true}
+
+				got, solution := SolveCheckmateWithOptions(gs, opts)
+				if got != p.checkmate {
+					t.Fatalf("SolveCheckmateWithOptions() = %v, want %v", got, p.checkmate)
+				}
+				if got {
+					checkSolutionTiles(t, p.board, p.hand, solution)
+				}
+			})
+		}
+	}
+}
+
+func TestSolveCheckmate_ParallelRules(t *testing.T) {
+	// 初手やジョーカーの判定がある局面でも直列の探索と同じ結果になる
+	board := Board{Melds: []Meld{{R3, R4, R5, JK}, {B9, Y9, K9}}}
+	for _, hand := range [][]Tile{{B9, Y9}, {R6, B10, Y10}, {B10, B11, B12}, {B5, B6, B7}} {
+		for _, opened := range []bool{true, false} {
+			gs := &GameState{Board: board, Hand: Hand{Tiles: hand}, Opened: opened}
+			opts := DefaultOptions()
+			want, _ := SolveCheckmateWithOptions(gs, opts)
+
+			opts.Workers = 4
+			got, solution := SolveCheckmateWithOptions(gs, opts)
+			if got != want {
+				t.Errorf("%v (opened %v): parallel = %v, serial = %v", hand, opened, got, want)
+			}
+			if got {
+				checkSolution(t, gs, opts.Rules, solution)
+			}
+		}
+	}
+}
+
+func TestSolveCheckmate_ParallelDeterministic(t *testing.T) {
+	for seed := uint64(1); seed <= 10; seed++ {
+		board, hand := generatePositionWithCopies(seed, 40, 2, 2)
+		gs := &GameState{Board: board, Hand: hand, Opened: true}
+
+		var want string
+		for _, workers := range []int{2, 3, 8, 8, 8} {
+			opts := DefaultOptions()
+			opts.Engine = EngineDLX
+			opts.Workers = workers
+			opts.Deterministic = true
+			got, solution := SolveCheckmateWithOptions(gs, opts)
+			if !got {
+				t.Fatalf("seed %d: no checkmate with %d workers", seed, workers)
+			}
+			checkSolutionTiles(t, board, hand, solution)
+
+			key := fmt.Sprint(solution)
+			if want == "" {
+				want = key
+			} else if key != want {
+				t.Errorf("seed %d: %d workers returned %s, want %s", seed, workers, key, want)
+			}
+		}
+	}
+}
+
+func TestParallelCover_Deadline(t *testing.T) {
+	// 解を採用しない判定ですべての被覆を探させ、途中で期限を切らす
+	board, hand := generatePosition(9, 60, 2)
+	tiles := collectTiles(board, hand)
+	infos := buildCandidateInfos(tiles, GenerateAllCandidatesWithRules(tiles, DefaultRules()))
+	reject := func([][]Tile) bool { return false }
+
+	for _, engine := range []Engine{EngineBacktrack, EngineDLX} {
+		for _, deterministic := range []bool{false, true} {
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+			mon := newMonitor(ctx)
+			_, ok := parallelCover(engine, tiles, infos, copyOrder(tiles, Tile.Notation), reject, mon, 4, deterministic)
+			cancel()
+			if ok || !errors.Is(mon.err, context.DeadlineExceeded) {
+				t.Errorf("%s (deterministic %v): parallelCover() = %v, %v, want context.DeadlineExceeded", engine, deterministic, ok, mon.err)
+			}
+		}
+	}
+}
+
+func BenchmarkSolveCheckmateParallel(b *testing.B) {
+	type benchPosition struct {
+		testPosition
+		engines []Engine
+	}
+	var positions []benchPosition
+	for _, size := range []int{40, 60} {
+		board, hand := generatePosition(uint64(size), size, 2)
+		positions = append(positions, benchPosition{
+			testPosition{name: fmt.Sprintf("Generated%d", size), board: board, hand: hand},
+			[]Engine{EngineBacktrack, EngineDLX},
+		})
+	}
+	// 最初の枝に解がなく、直列では深く探索する局面（backtrack は枝ごとの表では時間がかかるので dlx だけ）
+	board, hand := generatePosition(9, 60, 2)
+	hand.Tiles = append(hand.Tiles, R1, JK)
+	positions = append(positions, benchPosition{
+		testPosition{name: "Hard60", board: board, hand: hand},
+		[]Engine{EngineDLX},
+	})
+
+	for _, p := range positions {
+		for _, engine := range p.engines {
+			for _, workers := range []int{1, 4} {
+				b.Run(fmt.Sprintf("%s/%s/workers=%d", p.name, engine, workers), func(b *testing.B) {
+					opts := DefaultOptions()
+					opts.Engine = engine
+					opts.Workers = workers
+					gs := &GameState{Board: p.board, Hand: p.hand, Opened: true}
+					for b.Loop() {
+						SolveCheckmateWithOptions(gs, opts)
+					}
+				})
+			}
+		}
+	}
+}
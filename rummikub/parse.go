@@ -0,0 +1,166 @@
+package rummikub
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// GameStateJSON はJSON入力用の構造体
+type GameStateJSON struct {
+	Board [][]string `json:"board"`
+	Hand  []string   `json:"hand"`
+	// Opened は初手を済ませているか。省略時は済んでいるものとして扱う。
+	Opened *bool `json:"opened,omitempty"`
+}
+
+// ToJSON はゲームの状態をJSON入力と同じ形にする
+func (gs *GameState) ToJSON() GameStateJSON {
+	gsj := GameStateJSON{Board: [][]string{}, Hand: []string{}, Opened: &gs.Opened}
+	for _, meld := range gs.Board.Melds {
+		var tiles []string
+		for _, tile := range meld {
+			tiles = append(tiles, tile.Notation())
+		}
+		gsj.Board = append(gsj.Board, tiles)
+	}
+	for _, tile := range gs.Hand.Tiles {
+		gsj.Hand = append(gsj.Hand, tile.Notation())
+	}
+	return gsj
+}
+
+// colorMap は色の表記（1文字）と色の対応
+var colorMap = map[byte]Color{
+	'R': Red,
+	'B': Blue,
+	'Y': Yellow,
+	'K': Black,
+}
+
+// ParseColors は "RBYK" のような色の表記の並びから色の一覧を作成する。同じ色が重なれば誤り
+func ParseColors(s string) ([]Color, error) {
+	var colors []Color
+	for i := 0; i < len(s); i++ {
+		c, ok := colorMap[s[i]]
+		if !ok {
+			return nil, fmt.Errorf("invalid color: %c", s[i])
+		}
+		if slices.Contains(colors, c) {
+			return nil, fmt.Errorf("duplicate color: %c", s[i])
+		}
+		colors = append(colors, c)
+	}
+	return colors, nil
+}
+
+// ParseTile は "R1" や "JK" といった表記からタイルを作成する
+func ParseTile(s string) (Tile, error) {
+	return ParseTileWithRules(s, DefaultRules())
+}
+
+// ParseTileWithRules は指定したルールのデッキに含まれるタイルだけを受け付ける ParseTile
+func ParseTileWithRules(s string, r Rules) (Tile, error) {
+	r = r.withDefaults()
+	s = strings.TrimSpace(s)
+	t, ok := parseTileNotation(s)
+	if !ok || !r.validTile(t) {
+		return Tile{}, fmt.Errorf("invalid tile: %s", s)
+	}
+	return t, nil
+}
+
+// parseTileNotation は色の1文字と数字、または "JK" をタイルにする
+func parseTileNotation(s string) (Tile, bool) {
+	if s == "JK" {
+		return JK, true
+	}
+	if len(s) < 2 {
+		return Tile{}, false
+	}
+	color, ok := colorMap[s[0]]
+	if !ok {
+		return Tile{}, false
+	}
+	n, err := strconv.Atoi(s[1:])
+	if err != nil || n <= 0 || s[1] == '0' || s[1] == '+' {
+		return Tile{}, false
+	}
+	return NewTile(color, TileNumber(n)), true
+}
+
+// ParseGameState はJSONデータからゲームの状態を読み込む
+func ParseGameState(data []byte) (*GameState, error) {
+	return ParseGameStateWithRules(data, DefaultRules())
+}
+
+// ParseGameStateWithRules は指定したルールでJSONデータからゲームの状態を読み込む。
+// 読み込めないタイルや Validate で見つかった誤りは、すべてまとめて ValidationErrors として返す。
+// 読み込めたタイルは Validate と同じく確かめるが、読み込めないタイルを含む場のメルドはメルドとしては確かめない。
+// ルールに矛盾があれば Rules.Validate の誤りを返す。
+func ParseGameStateWithRules(data []byte, r Rules) (*GameState, error) {
+	if err := r.Validate(); err != nil {
+		return nil, err
+	}
+	r = r.withDefaults()
+	var gsj GameStateJSON
+	if err := json.Unmarshal(data, &gsj); err != nil {
+		return nil, err
+	}
+
+	gs := &GameState{Opened: gsj.Opened == nil || *gsj.Opened}
+	var errs ValidationErrors
+	origins := &tileOrigins{broken: make([]bool, len(gsj.Board))}
+
+	// Board変換
+	for i, meldStrings := range gsj.Board {
+		var tiles []Tile
+		var positions []int
+		for k, s := range meldStrings {
+			tile, err := ParseTileWithRules(s, r)
+			if err != nil {
+				errs = append(errs, &InvalidTileError{Location: Location{Meld: i, Position: k}, Text: s})
+				origins.broken[i] = true
+				continue
+			}
+			tiles = append(tiles, tile)
+			positions = append(positions, k)
+		}
+		gs.Board.Melds = append(gs.Board.Melds, Meld(tiles))
+		origins.board = append(origins.board, positions)
+	}
+
+	// Hand変換
+	for k, s := range gsj.Hand {
+		tile, err := ParseTileWithRules(s, r)
+		if err != nil {
+			errs = append(errs, &InvalidTileError{Location: Location{Hand: true, Position: k}, Text: s})
+			continue
+		}
+		gs.Hand.Tiles = append(gs.Hand.Tiles, tile)
+		origins.hand = append(origins.hand, k)
+	}
+
+	errs = append(errs, gs.validate(r, origins)...)
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	return gs, nil
+}
+
+// LoadGameState はJSONファイルからゲームの状態を読み込む
+func LoadGameState(filename string) (*GameState, error) {
+	return LoadGameStateWithRules(filename, DefaultRules())
+}
+
+// LoadGameStateWithRules は指定したルールでJSONファイルからゲームの状態を読み込む
+func LoadGameStateWithRules(filename string, r Rules) (*GameState, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	return ParseGameStateWithRules(data, r)
+}
@@ -0,0 +1,47 @@
+package rummikub
+
+import "testing"
+
+func TestParseGameState(t *testing.T) {
+	data := []byte(`{"board": [["R1","R2","R3"],["R7","B7","Y7"]], "hand": ["B5","B6","B7","JK"]}`)
+
+	gs, err := ParseGameState(data)
+	if err != nil {
+		t.Fatalf("ParseGameState() error = %v", err)
+	}
+	if len(gs.Board.Melds) != 2 {
+		t.Errorf("Expected 2 board melds, got %d", len(gs.Board.Melds))
+	}
+	if len(gs.Hand.Tiles) != 4 {
+		t.Errorf("Expected 4 hand tiles, got %d", len(gs.Hand.Tiles))
+	}
+	if !gs.Hand.Tiles[3].IsJoker {
+		t.Errorf("Expected last hand tile to be a joker, got %s", gs.Hand.Tiles[3])
+	}
+}
+
+func TestParseTile_Invalid(t *testing.T) {
+	for _, s := range []string{"", "R0", "R14", "X1", "J"} {
+		if _, err := ParseTile(s); err == nil {
+			t.Errorf("ParseTile(%q) expected error, got nil", s)
+		}
+	}
+}
+
+func TestParseGameState_Opened(t *testing.T) {
+	gs, err := ParseGameState([]byte(`{"board": [], "hand": ["R1"]}`))
+	if err != nil {
+		t.Fatalf("ParseGameState() error = %v", err)
+	}
+	if !gs.Opened {
+		t.Error("Expected opened by default")
+	}
+
+	gs, err = ParseGameState([]byte(`{"board": [], "hand": ["R1"], "opened": false}`))
+	if err != nil {
+		t.Fatalf("ParseGameState() error = %v", err)
+	}
+	if gs.Opened {
+		t.Error("Expected not opened")
+	}
+}
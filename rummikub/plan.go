@@ -0,0 +1,242 @@
+package rummikub
+
+import (
+	"fmt"
+	"slices"
+)
+
+// StepKind は組み替え手順の操作の種類
+type StepKind int
+
+const (
+	// StepLayDown は新しいメルドを出す
+	StepLayDown StepKind = iota
+	// StepAppend はメルドにタイルを付け足す
+	StepAppend
+	// StepSplit はメルドを指定位置で2つに分ける
+	StepSplit
+	// StepTake はメルドからタイルを1枚取る
+	StepTake
+	// StepSwapJoker はメルドのジョーカーを手札のタイルと入れ替えて取り出す
+	StepSwapJoker
+)
+
+// Step は組み替え手順の1操作。
+// メルドの番号は Board.String() と同じ1始まりで、
+// 元の場にないメルド（分割や新規で作ったもの）には続きの番号を振る。
+type Step struct {
+	Kind     StepKind
+	Meld     int    // 操作するメルドの番号
+	Position int    // StepSplit: この位置（1始まり）のタイルから後ろを分ける。StepTake/StepSwapJoker: タイルの位置
+	Tiles    []Tile // 出す・付け足す・取る・入れ替えに使うタイル
+	From     int    // StepLayDown/StepAppend: タイルを持ってきたメルドの番号。手札からなら0、複数の出所なら -1
+	NewMeld  int    // StepSplit: 分けた後ろ側のメルドの番号
+}
+
+func (s Step) String() string {
+	switch s.Kind {
+	case StepLayDown:
+		return fmt.Sprintf("メルド%dとして %s を出す%s", s.Meld, Meld(s.Tiles), fromLabel(s.From))
+	case StepAppend:
+		return fmt.Sprintf("メルド%dに %s を付け足す%s", s.Meld, Meld(s.Tiles), fromLabel(s.From))
+	case StepSplit:
+		return fmt.Sprintf("メルド%dを%d枚目の前で分け、後ろをメルド%dとする", s.Meld, s.Position, s.NewMeld)
+	case StepTake:
+		return fmt.Sprintf("メルド%dの%d枚目 %s を取る", s.Meld, s.Position, s.Tiles[0])
+	case StepSwapJoker:
+		return fmt.Sprintf("メルド%dの%d枚目 %s を手札の %s と入れ替える", s.Meld, s.Position, s.Tiles[0], s.Tiles[1])
+	default:
+		return "?"
+	}
+}
+
+// fromLabel はタイルの出所の表示を返す
+func fromLabel(from int) string {
+	switch from {
+	case 0:
+		return "（手札から）"
+	case -1:
+		return "（手札と取ったタイルから）"
+	}
+	return fmt.Sprintf("（メルド%dから）", from)
+}
+
+// planPiece は手順の途中で場にあるメルド（またはその一部）
+type planPiece struct {
+	label int
+	tiles []Tile
+}
+
+// planPool は場から取ったり手札から出したりする途中のタイル
+type planPool struct {
+	tile Tile
+	from int
+}
+
+// PlanMoves は元の場と手札から解に至るまでの組み替え手順を作る。
+// 先に場のメルドの分割・タイルの取り出しを行い、その後で解のメルドを順に組み立てる。
+// solution のタイルIDは SolveCheckmate と同じく、場→手札の順に振られている必要がある。
+func PlanMoves(board Board, hand Hand, solution []Meld) []Step {
+	return PlanMovesWithRules(board, hand, solution, DefaultRules())
+}
+
+// PlanMovesWithRules は指定したルールで PlanMoves を行う
+func PlanMovesWithRules(board Board, hand Hand, solution []Meld, r Rules) []Step {
+	r = r.withDefaults()
+	dest := meldOf(solution)
+	allTiles := collectTiles(board, hand)
+	boardCount := len(allTiles) - len(hand.Tiles)
+
+	var steps []Step
+	var pieces []planPiece
+	var pool []planPool
+	for _, tile := range allTiles[boardCount:] {
+		pool = append(pool, planPool{tile: tile})
+	}
+	nextLabel := len(board.Melds) + 1
+
+	for i, meld := range boardMelds(board, allTiles) {
+		label := i + 1
+		if len(meld) == 0 {
+			continue
+		}
+		tiles := slices.Clone([]Tile(meld))
+		anchor := dest[largestBlock(tiles, dest)[0].ID]
+
+		// 行き先の違う1枚だけのタイルは取り出す（ジョーカーは手札と入れ替えられれば入れ替える）
+		for pos := len(tiles) - 1; pos >= 0; pos-- {
+			tile := tiles[pos]
+			if dest[tile.ID] == anchor || blockSize(tiles, pos, dest) > 1 {
+				continue
+			}
+
+			if tile.IsJoker {
+				if k := swapCandidate(tiles, pos, pool, dest, anchor, r); k >= 0 {
+					steps = append(steps, Step{Kind: StepSwapJoker, Meld: label, Position: pos + 1, Tiles: []Tile{tile, pool[k].tile}})
+					tiles[pos] = pool[k].tile
+					pool[k] = planPool{tile: tile, from: label}
+					continue
+				}
+			}
+
+			steps = append(steps, Step{Kind: StepTake, Meld: label, Position: pos + 1, Tiles: []Tile{tile}})
+			tiles = slices.Delete(tiles, pos, pos+1)
+			pool = append(pool, planPool{tile: tile, from: label})
+		}
+
+		// 残りを行き先ごとのかたまりに分ける。位置がずれないよう後ろから分ける
+		var split []planPiece
+		for pos := len(tiles) - 1; pos > 0; pos-- {
+			if dest[tiles[pos].ID] == dest[tiles[pos-1].ID] {
+				continue
+			}
+			steps = append(steps, Step{Kind: StepSplit, Meld: label, Position: pos + 1, NewMeld: nextLabel})
+			split = append(split, planPiece{label: nextLabel, tiles: tiles[pos:]})
+			tiles = tiles[:pos]
+			nextLabel++
+		}
+		if len(tiles) > 0 {
+			pieces = append(pieces, planPiece{label: label, tiles: tiles})
+		}
+		pieces = append(pieces, split...)
+	}
+
+	// 解のメルドを組み立てる
+	for target, meld := range solution {
+		var base *planPiece
+		var others []planPiece
+		for k := range pieces {
+			if dest[pieces[k].tiles[0].ID] != target {
+				continue
+			}
+			if base == nil || len(pieces[k].tiles) > len(base.tiles) {
+				if base != nil {
+					others = append(others, *base)
+				}
+				base = &pieces[k]
+			} else {
+				others = append(others, pieces[k])
+			}
+		}
+
+		// 行き先が同じ取り出し済みのタイルを出所ごとにまとめる
+		var sources []int
+		bySource := make(map[int][]Tile)
+		for _, p := range pool {
+			if dest[p.tile.ID] != target {
+				continue
+			}
+			if _, ok := bySource[p.from]; !ok {
+				sources = append(sources, p.from)
+			}
+			bySource[p.from] = append(bySource[p.from], p.tile)
+		}
+
+		if base == nil {
+			steps = append(steps, Step{Kind: StepLayDown, Meld: nextLabel, Tiles: slices.Clone([]Tile(meld)), From: layDownSource(sources)})
+			nextLabel++
+			continue
+		}
+		for _, other := range others {
+			steps = append(steps, Step{Kind: StepAppend, Meld: base.label, Tiles: other.tiles, From: other.label})
+		}
+		for _, from := range sources {
+			steps = append(steps, Step{Kind: StepAppend, Meld: base.label, Tiles: bySource[from], From: from})
+		}
+	}
+	return steps
+}
+
+// layDownSource は新しく出すメルドのタイルがすべて同じ出所ならその番号を、
+// 出所が複数なら -1 を返す
+func layDownSource(sources []int) int {
+	if len(sources) == 1 {
+		return sources[0]
+	}
+	return -1
+}
+
+// blockSize は pos のタイルと行き先が同じで連続しているタイルの数を返す
+func blockSize(tiles []Tile, pos int, dest map[TileID]int) int {
+	start, end := pos, pos+1
+	for start > 0 && dest[tiles[start-1].ID] == dest[tiles[pos].ID] {
+		start--
+	}
+	for end < len(tiles) && dest[tiles[end].ID] == dest[tiles[pos].ID] {
+		end++
+	}
+	return end - start
+}
+
+// largestBlock は行き先が同じで連続しているタイルのうち最も長いかたまりを返す
+func largestBlock(tiles []Tile, dest map[TileID]int) []Tile {
+	var best []Tile
+	for start := 0; start < len(tiles); {
+		end := start + 1
+		for end < len(tiles) && dest[tiles[end].ID] == dest[tiles[start].ID] {
+			end++
+		}
+		if len(tiles[start:end]) > len(best) {
+			best = tiles[start:end]
+		}
+		start = end
+	}
+	return best
+}
+
+// swapCandidate は pos のジョーカーと入れ替えられる手札のタイルを pool から探す。
+// 入れ替えたタイルはメルドに残るので、行き先がメルド本体と同じで、
+// 入れ替えてもメルドが有効なままのタイルに限る。
+func swapCandidate(tiles []Tile, pos int, pool []planPool, dest map[TileID]int, anchor int, r Rules) int {
+	for k, p := range pool {
+		if p.from != 0 || p.tile.IsJoker || dest[p.tile.ID] != anchor {
+			continue
+		}
+		replaced := slices.Clone(tiles)
+		replaced[pos] = p.tile
+		if Meld(replaced).IsValidWith(r) {
+			return k
+		}
+	}
+	return -1
+}
@@ -0,0 +1,162 @@
+package rummikub
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+)
+
+// applySteps は手順を実際に場と手札へ適用し、最後の場を返す
+func applySteps(t *testing.T, board Board, hand Hand, steps []Step) map[int][]Tile {
+	t.Helper()
+
+	allTiles := collectTiles(board, hand)
+	table := make(map[int][]Tile)
+	for i, meld := range boardMelds(board, allTiles) {
+		table[i+1] = slices.Clone([]Tile(meld))
+	}
+	pool := slices.Clone(allTiles[len(allTiles)-len(hand.Tiles):])
+
+	takeFromPool := func(tile Tile) {
+		i := slices.IndexFunc(pool, func(p Tile) bool { return p.ID == tile.ID })
+		if i < 0 {
+			t.Fatalf("Tile %s is not available", tile)
+		}
+		pool = slices.Delete(pool, i, i+1)
+	}
+
+	for _, step := range steps {
+		t.Log(step)
+		switch step.Kind {
+		case StepLayDown:
+			for _, tile := range step.Tiles {
+				takeFromPool(tile)
+			}
+			table[step.Meld] = slices.Clone(step.Tiles)
+		case StepAppend:
+			for _, tile := range step.Tiles {
+				from := table[step.From]
+				if i := slices.IndexFunc(from, func(p Tile) bool { return p.ID == tile.ID }); i >= 0 {
+					table[step.From] = slices.Delete(from, i, i+1)
+					if len(table[step.From]) == 0 {
+						delete(table, step.From)
+					}
+				} else {
+					takeFromPool(tile)
+				}
+			}
+			table[step.Meld] = append(table[step.Meld], step.Tiles...)
+		case StepSplit:
+			meld := table[step.Meld]
+			table[step.NewMeld] = slices.Clone(meld[step.Position-1:])
+			table[step.Meld] = meld[:step.Position-1]
+		case StepTake:
+			meld := table[step.Meld]
+			if meld[step.Position-1].ID != step.Tiles[0].ID {
+				t.Fatalf("Tile %s is not at position %d of meld %d", step.Tiles[0], step.Position, step.Meld)
+			}
+			table[step.Meld] = slices.Delete(meld, step.Position-1, step.Position)
+			pool = append(pool, step.Tiles[0])
+		case StepSwapJoker:
+			meld := table[step.Meld]
+			if meld[step.Position-1].ID != step.Tiles[0].ID {
+				t.Fatalf("Joker is not at position %d of meld %d", step.Position, step.Meld)
+			}
+			takeFromPool(step.Tiles[1])
+			meld[step.Position-1] = step.Tiles[1]
+			pool = append(pool, step.Tiles[0])
+		}
+	}
+
+	if len(pool) != 0 {
+		t.Errorf("Tiles left over after plan: %v", pool)
+	}
+	return table
+}
+
+// checkPlan は手順を適用した結果が解と一致するかを確認する
+func checkPlan(t *testing.T, board Board, hand Hand, solution []Meld) []Step {
+	t.Helper()
+
+	steps := PlanMoves(board, hand, solution)
+	table := applySteps(t, board, hand, steps)
+
+	var got, want []string
+	for _, meld := range table {
+		got = append(got, meldIDKey(meld))
+	}
+	for _, meld := range solution {
+		want = append(want, meldIDKey(meld))
+	}
+	slices.Sort(got)
+	slices.Sort(want)
+	if !slices.Equal(got, want) {
+		t.Errorf("Plan result %v does not match solution %v", table, solution)
+	}
+	return steps
+}
+
+// meldIDKey はメルドのタイルIDを整列した文字列を返す
+func meldIDKey(meld []Tile) string {
+	ids := make([]int, len(meld))
+	for i, tile := range meld {
+		ids[i] = int(tile.ID)
+	}
+	slices.Sort(ids)
+	return fmt.Sprint(ids)
+}
+
+func TestPlanMoves_Positions(t *testing.T) {
+	for _, p := range testPositions {
+		t.Run(p.name, func(t *testing.T) {
+			ok, solution := SolveCheckmate(p.board, p.hand)
+			if !ok {
+				return
+			}
+			checkPlan(t, p.board, p.hand, solution)
+		})
+	}
+}
+
+func TestPlanMoves_Operations(t *testing.T) {
+	board := Board{Melds: []Meld{
+		{R4, R5, R6, R7, R8, R9},
+		{B7, Y7, K7},
+	}}
+	hand := Hand{Tiles: []Tile{R10}}
+	tiles := collectTiles(board, hand)
+
+	// [R4 R5 R6] [R7 B7 Y7 K7] [R8 R9 R10]
+	solution := []Meld{
+		{tiles[0], tiles[1], tiles[2]},
+		{tiles[3], tiles[6], tiles[7], tiles[8]},
+		{tiles[4], tiles[5], tiles[9]},
+	}
+
+	steps := checkPlan(t, board, hand, solution)
+	kinds := make(map[StepKind]int)
+	for _, step := range steps {
+		kinds[step.Kind]++
+	}
+	if kinds[StepTake] != 1 || kinds[StepSplit] != 1 {
+		t.Errorf("Expected 1 take and 1 split, got %v", kinds)
+	}
+}
+
+func TestPlanMoves_SwapJoker(t *testing.T) {
+	board := Board{Melds: []Meld{
+		{R4, JK, R6},
+	}}
+	hand := Hand{Tiles: []Tile{R5, B9, Y9}}
+	tiles := collectTiles(board, hand)
+
+	solution := []Meld{
+		{tiles[0], tiles[3], tiles[2]},
+		{tiles[1], tiles[4], tiles[5]},
+	}
+
+	steps := checkPlan(t, board, hand, solution)
+	if steps[0].Kind != StepSwapJoker {
+		t.Errorf("Expected first step to swap the joker, got %s", steps[0])
+	}
+}
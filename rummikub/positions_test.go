@@ -0,0 +1,113 @@
+package rummikub
+
+import (
+	"math/rand/v2"
+	"testing"
+)
+
+// testPosition は複数のエンジン・ソルバーで共通に使うテスト用の局面
+type testPosition struct {
+	name      string
+	board     Board
+	hand      Hand
+	checkmate bool
+}
+
+// testPositions は solver_test.go の各ケースと同じ局面
+var testPositions = []testPosition{
+	{"SimpleRun", Board{Melds: []Meld{{R1, R2, R3}}}, Hand{Tiles: []Tile{B5, B6, B7}}, true},
+	{"WithJoker", Board{Melds: []Meld{{R1, R2, R3}, {R7, B7, Y7}}}, Hand{Tiles: []Tile{B5, B6, B7, JK}}, true},
+	{"NoSolution", Board{Melds: []Meld{{R1, R2, R3}}}, Hand{Tiles: []Tile{B5, B6, Y7}}, false},
+	{"EmptyHand", Board{Melds: []Meld{{R1, R2, R3}}}, Hand{Tiles: []Tile{}}, true},
+	{"GroupOnly", Board{}, Hand{Tiles: []Tile{R7, B7, Y7}}, true},
+	{"FourColorGroup", Board{}, Hand{Tiles: []Tile{R7, B7, Y7, K7}}, true},
+	{"JokerFillsGap", Board{}, Hand{Tiles: []Tile{R1, R3, JK}}, true},
+	{"ComplexRearrangement", Board{Melds: []Meld{{R1, R2, R3}, {B1, B2, B3}}}, Hand{Tiles: []Tile{Y1, Y2, Y3}}, true},
+	{"HeavyRearrangement", Board{Melds: []Meld{
+		{K1, K2, K3},
+		{K4, Y4, B4},
+		{R4, R5, R6},
+		{R7, K7, B7},
+		{R13, B13, Y13},
+		{B10, B11, B12},
+		{K10, R10, Y10},
+		{Y7, Y8, Y9},
+	}}, Hand{Tiles: []Tile{B1, Y1, B13}}, true},
+	{"DuplicateRuns", Board{Melds: []Meld{{R3, R4, R5}, {R5, R6, R7}}}, Hand{Tiles: []Tile{R8}}, true},
+	{"DuplicateGroups", Board{Melds: []Meld{{R5, B5, Y5}}}, Hand{Tiles: []Tile{R5, B5, K5}}, true},
+	{"DuplicateInHand", Board{}, Hand{Tiles: []Tile{B4, B5, B6, B5, B6, B7}}, true},
+	{"DuplicateSplit", Board{Melds: []Meld{{Y1, Y2, Y3, Y4, Y5, Y6}}}, Hand{Tiles: []Tile{Y3, Y4, Y5}}, true},
+}
+
+// findPosition は名前で testPositions の局面を引く
+func findPosition(t testing.TB, name string) testPosition {
+	t.Helper()
+	for _, p := range testPositions {
+		if p.name == name {
+			return p
+		}
+	}
+	t.Fatalf("no test position %q", name)
+	return testPosition{}
+}
+
+// generatePosition は乱数で詰みのある局面を生成する。
+// 各タイル1枚ずつのデッキからランとグループを作って場に並べ、
+// 最後のいくつかのメルドを崩して手札にする。
+func generatePosition(seed uint64, boardTiles, handMelds int) (Board, Hand) {
+	return generatePositionWithCopies(seed, boardTiles, handMelds, 1)
+}
+
+// generatePositionWithCopies は各タイル copies 枚ずつのデッキで generatePosition を行う
+func generatePositionWithCopies(seed uint64, boardTiles, handMelds, copies int) (Board, Hand) {
+	rng := rand.New(rand.NewPCG(seed, seed))
+	colors := []Color{Red, Blue, Yellow, Black}
+	used := make(map[Tile]int)
+
+	available := func(tiles []Tile) bool {
+		for _, t := range tiles {
+			if used[t] >= copies {
+				return false
+			}
+		}
+		return true
+	}
+
+	var melds []Meld
+	count := 0
+	for attempts := 0; attempts < 1000 && (count < boardTiles || len(melds) < handMelds+1); attempts++ {
+		var meld Meld
+		if rng.IntN(2) == 0 {
+			color := colors[rng.IntN(len(colors))]
+			length := 3 + rng.IntN(3)
+			start := 1 + rng.IntN(13-length+1)
+			for n := start; n < start+length; n++ {
+				meld = append(meld, NewTile(color, TileNumber(n)))
+			}
+		} else {
+			number := TileNumber(1 + rng.IntN(13))
+			perm := rng.Perm(len(colors))
+			size := 3 + rng.IntN(2)
+			for _, i := range perm[:size] {
+				meld = append(meld, NewTile(colors[i], number))
+			}
+		}
+		if !available(meld) {
+			continue
+		}
+		for _, t := range meld {
+			used[t]++
+		}
+		melds = append(melds, meld)
+		count += len(meld)
+	}
+
+	var hand Hand
+	for _, meld := range melds[len(melds)-handMelds:] {
+		hand.Tiles = append(hand.Tiles, meld...)
+	}
+	rng.Shuffle(len(hand.Tiles), func(i, j int) {
+		hand.Tiles[i], hand.Tiles[j] = hand.Tiles[j], hand.Tiles[i]
+	})
+	return Board{Melds: melds[:len(melds)-handMelds]}, hand
+}
@@ -0,0 +1,118 @@
+package rummikub
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// InfeasibleError は探索の前の確認で詰みがないと分かった理由。
+// Check は "no-meld"（どの候補メルドにも入らないタイルがある）か、
+// "jokers"（ジョーカーの要るタイルに対してジョーカーが足りない）。
+type InfeasibleError struct {
+	Check string
+	Tiles []Tile // 理由になったタイル
+	Need  int    // "jokers" のとき、Tiles を置くのに要るジョーカーの枚数
+	Have  int    // "jokers" のとき、使えるジョーカーの枚数
+}
+
+func (e *InfeasibleError) Error() string {
+	names := make([]string, len(e.Tiles))
+	for i, tile := range e.Tiles {
+		names[i] = tile.Notation()
+	}
+	if e.Check == "jokers" {
+		return fmt.Sprintf("%s need %d jokers, but only %d available", strings.Join(names, " "), e.Need, e.Have)
+	}
+	return fmt.Sprintf("no meld can contain %s", strings.Join(names, " "))
+}
+
+// Precheck は探索をせずに分かる範囲で、詰みがないことを確かめる。
+// 詰みがないと分かれば *InfeasibleError を返す。nil なら詰みがあるとは限らない。
+func Precheck(gs *GameState, opts Options) error {
+	opts.Rules = opts.Rules.withDefaults()
+	tiles, _ := coverTiles(gs, opts.Rules, collectTiles(gs.Board, gs.Hand))
+	if err := precheck(tiles, buildCandidateInfos(tiles, GenerateAllCandidatesWithRules(tiles, opts.Rules))); err != nil {
+		return err
+	}
+	return nil
+}
+
+// precheck は exactCover の前に、解があるための必要条件を確かめる。
+//   - どのタイルも、いずれかの候補に入っている（同じ色の隣の数字も、3色目の同じ数字もないタイルなどを見つける）
+//   - ジョーカーなしでは置けないタイルを別々のメルドに置くのに、ジョーカーが足りている
+func precheck(tiles []Tile, candidates []candidateInfo) *InfeasibleError {
+	// タイルごとに、そのタイルを含む候補が使うジョーカーの最小の枚数
+	need := make([]int, len(tiles))
+	for i := range need {
+		need[i] = -1
+	}
+	for _, c := range candidates {
+		jokers := 0
+		for _, tile := range c.tiles {
+			if tile.IsJoker {
+				jokers++
+			}
+		}
+		for _, idx := range c.indices {
+			if need[idx] == -1 || jokers < need[idx] {
+				need[idx] = jokers
+			}
+		}
+	}
+
+	var uncovered []Tile
+	for i, n := range need {
+		if n == -1 {
+			uncovered = append(uncovered, tiles[i])
+		}
+	}
+	if len(uncovered) > 0 {
+		return &InfeasibleError{Check: "no-meld", Tiles: uncovered}
+	}
+
+	// ジョーカーが要るタイルのうち、同じ候補に入らないものを要る枚数の多い順に選ぶ。
+	// 選んだタイルはそれぞれ別のメルドに置くので、要る枚数の合計だけジョーカーが必要になる
+	have := 0
+	var needy []int
+	for i, tile := range tiles {
+		if tile.IsJoker {
+			have++
+		} else if need[i] > 0 {
+			needy = append(needy, i)
+		}
+	}
+	if len(needy) == 0 {
+		return nil
+	}
+	slices.SortStableFunc(needy, func(a, b int) int { return cmp.Compare(need[b], need[a]) })
+
+	together := make([]tileSet, len(tiles)) // ジョーカーが要るタイルと同じ候補に入るタイル
+	for _, i := range needy {
+		together[i] = newTileSet(len(tiles))
+	}
+	for _, c := range candidates {
+		for _, idx := range c.indices {
+			if together[idx] != nil {
+				together[idx].union(c.mask)
+			}
+		}
+	}
+
+	var picked []Tile
+	chosen := newTileSet(len(tiles))
+	total := 0
+	for _, i := range needy {
+		if !together[i].disjoint(chosen) {
+			continue
+		}
+		chosen.add(i)
+		picked = append(picked, tiles[i])
+		total += need[i]
+	}
+	if total > have {
+		return &InfeasibleError{Check: "jokers", Tiles: picked, Need: total, Have: have}
+	}
+	return nil
+}
@@ -0,0 +1,107 @@
+package rummikub
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"slices"
+	"testing"
+)
+
+func TestPrecheck(t *testing.T) {
+	tests := []struct {
+		name   string
+		board  Board
+		hand   []Tile
+		opened bool
+		want   *InfeasibleError
+	}{
+		{"lone tiles", Board{Melds: []Meld{{R5, R6, R7}}}, []Tile{R1, K9}, true,
+			&InfeasibleError{Check: "no-meld", Tiles: []Tile{R1, K9}}},
+		{"not enough jokers", Board{}, []Tile{R1, B13, JK, JK}, true,
+			&InfeasibleError{Check: "jokers", Tiles: []Tile{R1, B13}, Need: 4, Have: 2}},
+		{"copies need separate melds", Board{Melds: []Meld{{R1, R2, R3}}}, []Tile{R5, R5, JK}, true,
+			&InfeasibleError{Check: "jokers", Tiles: []Tile{R5, R5}, Need: 2, Have: 1}},
+		{"board before opening", Board{Melds: []Meld{{K10, K11, K12}}}, []Tile{R1, R2, R3, K9}, false,
+			&InfeasibleError{Check: "no-meld", Tiles: []Tile{K9}}},
+		{"enough jokers", Board{}, []Tile{R1, B13, JK, JK, JK, JK}, true, nil},
+		{"board melds help", Board{Melds: []Meld{{K10, K11, K12}}}, []Tile{K9}, true, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := DefaultOptions()
+			opts.Rules.Jokers = 4
+			gs := &GameState{Board: tt.board, Hand: Hand{Tiles: tt.hand}, Opened: tt.opened}
+			err := Precheck(gs, opts)
+
+			// 詰み判定は同じ確認の理由を統計に残す
+			var stats Stats
+			opts.Stats = &stats
+			ok, _, serr := SolveCheckmateContext(context.Background(), gs, opts)
+			if serr != nil {
+				t.Fatalf("SolveCheckmateContext() error = %v", serr)
+			}
+			if fmt.Sprint(stats.Infeasible) != fmt.Sprint(tt.want) {
+				t.Errorf("Stats.Infeasible = %v, want %v", stats.Infeasible, tt.want)
+			}
+
+			if tt.want == nil {
+				if err != nil {
+					t.Fatalf("Precheck() = %v, want nil", err)
+				}
+				return
+			}
+
+			var got *InfeasibleError
+			if !errors.As(err, &got) {
+				t.Fatalf("Precheck() = %v, want %v", err, tt.want)
+			}
+			if got.Check != tt.want.Check || meldKey(got.Tiles) != meldKey(tt.want.Tiles) || got.Need != tt.want.Need || got.Have != tt.want.Have {
+				t.Errorf("Precheck() = %+v, want %+v", got, tt.want)
+			}
+			if ok {
+				t.Errorf("SolveCheckmateContext() = true for an infeasible position")
+			}
+		})
+	}
+}
+
+func TestPrecheck_Sound(t *testing.T) {
+	// 確認で詰みなしとした局面は、探索しても覆えない
+	for _, p := range testPositions {
+		gs := &GameState{Board: p.board, Hand: p.hand, Opened: true}
+		if err := Precheck(gs, DefaultOptions()); p.checkmate && err != nil {
+			t.Errorf("%s: Precheck() = %v for a position with checkmate", p.name, err)
+		}
+	}
+
+	var deck []Tile
+	for _, color := range []Color{Red, Blue, Yellow} {
+		for n := TileNumber(1); n <= 6; n++ {
+			deck = append(deck, NewTile(color, n), NewTile(color, n))
+		}
+	}
+	deck = append(deck, JK, JK)
+
+	rng := rand.New(rand.NewPCG(7, 8))
+	rejected := 0
+	for i := 0; i < 300; i++ {
+		rng.Shuffle(len(deck), func(a, b int) { deck[a], deck[b] = deck[b], deck[a] })
+		tiles := collectTiles(Board{}, Hand{Tiles: slices.Clone(deck[:8])})
+		infos := buildCandidateInfos(tiles, GenerateAllCandidatesWithRules(tiles, DefaultRules()))
+		if precheck(tiles, infos) == nil {
+			continue
+		}
+		rejected++
+		for _, engine := range []Engine{EngineBacktrack, EngineDLX} {
+			mon := newMonitor(context.Background())
+			if _, ok := solveCover(engine, tiles, infos, nil, nil, mon); ok {
+				t.Errorf("%s: precheck rejected %s, but it can be covered", engine, Meld(tiles))
+			}
+		}
+	}
+	if rejected == 0 {
+		t.Errorf("precheck rejected no random position")
+	}
+}
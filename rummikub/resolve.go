@@ -0,0 +1,251 @@
+package rummikub
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Resolution はメルドの解釈。Tiles はメルドと同じ並びで、
+// ジョーカーの位置には代わりをしているタイルが入る
+type Resolution struct {
+	Kind  MeldKind
+	Meld  Meld
+	Tiles []Tile
+}
+
+// String は "[R7 JK=R8 R9]" のように、ジョーカーが代わりをしているタイルを添えた表記を返す
+func (res Resolution) String() string {
+	parts := make([]string, len(res.Meld))
+	for i, tile := range res.Meld {
+		parts[i] = tile.Notation()
+		if tile.IsJoker {
+			parts[i] += "=" + res.Tiles[i].Notation()
+		}
+	}
+	return "[" + strings.Join(parts, " ") + "]"
+}
+
+// Jokers はジョーカーが代わりをしているタイルを、ジョーカーの並び順に返す
+func (res Resolution) Jokers() []Tile {
+	var result []Tile
+	for i, tile := range res.Meld {
+		if tile.IsJoker {
+			result = append(result, res.Tiles[i])
+		}
+	}
+	return result
+}
+
+// MeldError はメルドが無効な理由
+type MeldError struct {
+	Meld   Meld
+	Reason string
+}
+
+func (e *MeldError) Error() string {
+	return fmt.Sprintf("%s: %s", meldNotation(e.Meld), e.Reason)
+}
+
+// Resolve はメルドを公式ルールで解釈し、各ジョーカーが代わりをしているタイルを決める
+func (m Meld) Resolve(ordered bool) (Resolution, error) {
+	return m.ResolveWith(DefaultRules(), ordered)
+}
+
+// ResolveWith は指定したルールでメルドを解釈する。
+// ordered が true なら、ランはタイルを並べた順に数字が1ずつ増えていなければならない（折り返しありなら13→1と続く）。
+// ordered が false なら並び順は問わず、並んでいなければ PointsWith と同じく点数が最も大きくなる開始の数字で
+// 足りない数字を小さい順にジョーカーへ割り当てる。
+// グループのジョーカーには足りない色を Rules.Colors の順に割り当てる。
+// ランとしてもグループとしても有効ならランとして解釈する。無効なら *MeldError を返す。
+func (m Meld) ResolveWith(r Rules, ordered bool) (Resolution, error) {
+	r = r.withDefaults()
+	invalid := func(format string, args ...any) (Resolution, error) {
+		return Resolution{}, &MeldError{Meld: m, Reason: fmt.Sprintf(format, args...)}
+	}
+
+	if len(m) < r.MinMeldSize {
+		return invalid("too short (min %d)", r.MinMeldSize)
+	}
+	for _, tile := range m {
+		if !r.validTile(tile) {
+			return invalid("%s is not in the deck", tile.Notation())
+		}
+	}
+
+	runErr := m.runError(r, ordered)
+	if runErr == "" {
+		return Resolution{Kind: MeldRun, Meld: m, Tiles: m.resolveRun(r)}, nil
+	}
+	groupErr := m.groupError(r)
+	if groupErr == "" {
+		return Resolution{Kind: MeldGroup, Meld: m, Tiles: m.resolveGroup(r)}, nil
+	}
+
+	// 色がそろっていればランとして、数字がそろっていればグループとしての理由を返す
+	if m.sameColor() {
+		return invalid("%s", runErr)
+	}
+	return invalid("%s", groupErr)
+}
+
+// sameColor はジョーカー以外のタイルがすべて同じ色かを返す
+func (m Meld) sameColor() bool {
+	var color Color
+	first := true
+	for _, tile := range m {
+		if tile.IsJoker {
+			continue
+		}
+		if !first && tile.Color != color {
+			return false
+		}
+		color, first = tile.Color, false
+	}
+	return true
+}
+
+// runError はランとして無効な理由を返す。有効なら空文字列
+func (m Meld) runError(r Rules, ordered bool) string {
+	if !m.sameColor() {
+		return "mixed colors in a run"
+	}
+	if len(m) > r.numberCount() {
+		return fmt.Sprintf("run longer than %d", r.numberCount())
+	}
+	numbers := make(map[TileNumber]bool)
+	for _, tile := range m {
+		if !tile.IsJoker {
+			if numbers[tile.Number] {
+				return fmt.Sprintf("duplicate number %d in a run", tile.Number)
+			}
+			numbers[tile.Number] = true
+		}
+	}
+	if len(m.runStarts(r)) == 0 {
+		return fmt.Sprintf("numbers cannot be consecutive within %d-%d", r.MinNumber, r.MaxNumber)
+	}
+	if _, ok := m.orderedStart(r); ordered && !ok {
+		return fmt.Sprintf("tiles are not in ascending order within %d-%d", r.MinNumber, r.MaxNumber)
+	}
+	return ""
+}
+
+// groupError はグループとして無効な理由を返す。有効なら空文字列
+func (m Meld) groupError(r Rules) string {
+	if len(m) > r.colorCount() {
+		return fmt.Sprintf("group larger than %d", r.colorCount())
+	}
+	var number TileNumber
+	colors := make(map[Color]bool)
+	for _, tile := range m {
+		if tile.IsJoker {
+			continue
+		}
+		if len(colors) > 0 && tile.Number != number {
+			return "mixed numbers in a group"
+		}
+		if colors[tile.Color] {
+			return fmt.Sprintf("duplicate color %s in a group", tile.Color)
+		}
+		number = tile.Number
+		colors[tile.Color] = true
+	}
+	return ""
+}
+
+// resolveRun は有効なランの各位置が表すタイルを返す。並び順どおりに解釈できればそれを優先する
+func (m Meld) resolveRun(r Rules) []Tile {
+	color := r.Colors[0]
+	for _, tile := range m {
+		if !tile.IsJoker {
+			color = tile.Color
+		}
+	}
+
+	result := make([]Tile, len(m))
+	if start, ok := m.orderedStart(r); ok {
+		for k := range m {
+			result[k] = NewTile(color, r.runNumber(start, k))
+		}
+		return result
+	}
+
+	// 並んでいなければ、PointsWith と同じ開始の数字で、足りない数字を小さい順にジョーカーへ割り当てる
+	start, _ := m.runStart(r)
+	present := make(map[TileNumber]bool)
+	for _, tile := range m {
+		if !tile.IsJoker {
+			present[tile.Number] = true
+		}
+	}
+	var missing []Tile
+	for k := range m {
+		if n := r.runNumber(start, k); !present[n] {
+			missing = append(missing, NewTile(color, n))
+		}
+	}
+	for k, tile := range m {
+		if tile.IsJoker {
+			result[k], missing = missing[0], missing[1:]
+		} else {
+			result[k] = NewTile(tile.Color, tile.Number)
+		}
+	}
+	return result
+}
+
+// arrangeRun は並び順によらず有効なランを、runStart から数字の順に並べ直したメルドを返す。
+// ジョーカーは足りない数字の位置に置くので、並べ直したメルドの Resolve は PointsWith と同じ数字を表す。
+// ランとして解釈しないメルドはそのまま返す。
+func (m Meld) arrangeRun(r Rules) Meld {
+	if m.runError(r, false) != "" {
+		return m
+	}
+	start, _ := m.runStart(r)
+	byNumber := make(map[TileNumber]Tile)
+	var jokers []Tile
+	for _, tile := range m {
+		if tile.IsJoker {
+			jokers = append(jokers, tile)
+		} else {
+			byNumber[tile.Number] = tile
+		}
+	}
+	result := make(Meld, 0, len(m))
+	for k := range m {
+		if tile, ok := byNumber[r.runNumber(start, k)]; ok {
+			result = append(result, tile)
+		} else {
+			result, jokers = append(result, jokers[0]), jokers[1:]
+		}
+	}
+	return result
+}
+
+// resolveGroup は有効なグループの各位置が表すタイルを返す
+func (m Meld) resolveGroup(r Rules) []Tile {
+	number := r.MinNumber
+	present := make(map[Color]bool)
+	for _, tile := range m {
+		if !tile.IsJoker {
+			number = tile.Number
+			present[tile.Color] = true
+		}
+	}
+	var missing []Tile
+	for _, color := range r.Colors {
+		if !present[color] {
+			missing = append(missing, NewTile(color, number))
+		}
+	}
+
+	result := make([]Tile, len(m))
+	for k, tile := range m {
+		if tile.IsJoker {
+			result[k], missing = missing[0], missing[1:]
+		} else {
+			result[k] = NewTile(tile.Color, tile.Number)
+		}
+	}
+	return result
+}
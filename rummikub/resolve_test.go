@@ -0,0 +1,138 @@
+package rummikub
+
+import (
+	"errors"
+	"math/rand/v2"
+	"testing"
+)
+
+func TestMeld_Resolve(t *testing.T) {
+	tests := []struct {
+		name    string
+		meld    Meld
+		ordered bool
+		want    string // 解釈の表記。無効なら空
+		kind    MeldKind
+	}{
+		{"run middle", Meld{B7, JK, B9}, true, "[B7 JK=B8 B9]", MeldRun},
+		{"run ends", Meld{JK, B5, B6, JK}, true, "[JK=B4 B5 B6 JK=B7]", MeldRun},
+		{"group", Meld{R5, JK, Y5}, true, "[R5 JK=B5 Y5]", MeldGroup},
+		{"joker past 13", Meld{R12, R13, JK}, true, "", MeldInvalid},
+		{"joker past 13 unordered", Meld{R12, R13, JK}, false, "[R12 R13 JK=R11]", MeldRun},
+		{"joker before 1", Meld{JK, R1, R2}, true, "", MeldInvalid},
+		{"descending", Meld{R3, R2, R1}, true, "", MeldInvalid},
+		{"descending unordered", Meld{R3, R2, R1}, false, "[R3 R2 R1]", MeldRun},
+		{"two jokers after 13", Meld{R12, R13, JK, JK}, true, "", MeldInvalid},
+		{"two jokers unordered", Meld{R12, R13, JK, JK}, false, "[R12 R13 JK=R10 JK=R11]", MeldRun},
+		{"edge joker unordered", Meld{R5, JK, R6}, false, "[R5 JK=R7 R6]", MeldRun},
+		{"too long", Meld{R1, R2, R3, R4, R5, R6, R7, R8, R9, R10, R11, R12, R13, JK}, false, "", MeldInvalid},
+		{"mixed", Meld{R1, B2, Y3}, false, "", MeldInvalid},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := tt.meld.Resolve(tt.ordered)
+			if tt.want == "" {
+				var meldErr *MeldError
+				if !errors.As(err, &meldErr) {
+					t.Fatalf("Resolve(%v) = %s, %v, want MeldError", tt.ordered, res, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Resolve(%v) error = %v", tt.ordered, err)
+			}
+			if got := res.String(); got != tt.want || res.Kind != tt.kind {
+				t.Errorf("Resolve(%v) = %s (%s), want %s (%s)", tt.ordered, got, res.Kind, tt.want, tt.kind)
+			}
+		})
+	}
+}
+
+func TestMeld_ResolveWith_Wrap(t *testing.T) {
+	r := DefaultRules()
+	r.WrapRuns = true
+
+	res, err := Meld{R13, JK, R2}.ResolveWith(r, true)
+	if err != nil {
+		t.Fatalf("ResolveWith() error = %v", err)
+	}
+	if got := res.String(); got != "[R13 JK=R1 R2]" {
+		t.Errorf("ResolveWith() = %s, want [R13 JK=R1 R2]", got)
+	}
+}
+
+func TestMeld_Resolve_AgreesWithPoints(t *testing.T) {
+	// 並んでいないランの端のジョーカーは、PointsWith が数える数字を表す
+	wrap := DefaultRules()
+	wrap.WrapRuns = true
+	tests := []struct {
+		meld  Meld
+		rules Rules
+	}{
+		{Meld{R5, JK, R6}, DefaultRules()},
+		{Meld{JK, R3, R2}, DefaultRules()},
+		{Meld{R9, JK, JK, R8}, DefaultRules()},
+		{Meld{R1, JK, R13}, wrap},
+		{Meld{R2, R13, JK}, wrap},
+	}
+
+	for _, tt := range tests {
+		res, err := tt.meld.ResolveWith(tt.rules, false)
+		if err != nil {
+			t.Fatalf("%s.ResolveWith() error = %v", tt.meld, err)
+		}
+		sum := 0
+		for _, tile := range res.Tiles {
+			sum += int(tile.Number)
+		}
+		if want := tt.meld.PointsWith(tt.rules); sum != want {
+			t.Errorf("%s.ResolveWith() = %s (%d points), want %d points", tt.meld, res, sum, want)
+		}
+	}
+}
+
+func TestMeld_Resolve_AgreesWithIsValid(t *testing.T) {
+	var deck []Tile
+	for _, color := range []Color{Red, Blue, Yellow, Black} {
+		for n := TileNumber(1); n <= 13; n++ {
+			deck = append(deck, NewTile(color, n))
+		}
+	}
+	deck = append(deck, JK, JK)
+
+	rng := rand.New(rand.NewPCG(7, 8))
+	for i := 0; i < 5000; i++ {
+		var meld Meld
+		// 同じ色か同じ数字に寄せて、有効なメルドも十分に作る
+		base := deck[rng.IntN(len(deck))]
+		for k := 0; k < 3+rng.IntN(3); k++ {
+			switch rng.IntN(3) {
+			case 0:
+				meld = append(meld, NewTile(base.Color, TileNumber(1+rng.IntN(13))))
+			case 1:
+				meld = append(meld, NewTile(Color(rng.IntN(4)), base.Number))
+			default:
+				meld = append(meld, deck[rng.IntN(len(deck))])
+			}
+		}
+
+		res, err := meld.Resolve(false)
+		if (err == nil) != meld.IsValid() {
+			t.Fatalf("%s: Resolve() error = %v, IsValid() = %v", meld, err, meld.IsValid())
+		}
+		if err != nil {
+			continue
+		}
+		// ジョーカーを解釈どおりのタイルに置き換えても有効で、同じ種類のメルドになる
+		replaced := Meld(res.Tiles)
+		if replaced.Kind() != res.Kind {
+			t.Errorf("%s: resolved %s is %s, want %s", meld, res, replaced.Kind(), res.Kind)
+		}
+		if _, err := meld.Resolve(true); err == nil {
+			if _, err := replaced.Resolve(true); err != nil {
+				t.Errorf("%s: resolved %s is not an ordered meld: %v", meld, res, err)
+			}
+		}
+	}
+}
@@ -0,0 +1,201 @@
+package rummikub
+
+import (
+	"fmt"
+	"slices"
+)
+
+// Rules はゲームのルール設定
+type Rules struct {
+	// MinNumber, MaxNumber はタイルの数字の範囲
+	MinNumber, MaxNumber TileNumber
+	// Colors は使う色
+	Colors []Color
+	// Copies は同じタイルの枚数
+	Copies int
+	// Jokers はジョーカーの枚数。0 なら公式ルールの枚数、負ならジョーカーなし
+	Jokers int
+	// MinMeldSize はメルドの最小枚数。グループの最大枚数は色の数
+	MinMeldSize int
+	// WrapRuns が true なら、13→1 と折り返すランを認める（ハウスルール）
+	WrapRuns bool
+
+	// InitialMeldPoints は初手（最初に場に出すとき）に必要な合計点数。0 なら公式ルールの点数、負なら点数を問わない
+	InitialMeldPoints int
+	// OpeningManipulation が true なら、初手と同じターンに場のメルドを組み替えられる（ハウスルール）
+	OpeningManipulation bool
+	// FreeJokers が true なら、場のジョーカーを入れ替えなしで自由に動かせる（ハウスルール）
+	FreeJokers bool
+}
+
+// DefaultRules は公式ルール（1〜13、4色、各2枚、ジョーカー2枚）を返す
+func DefaultRules() Rules {
+	return Rules{
+		MinNumber:         1,
+		MaxNumber:         13,
+		Colors:            []Color{Red, Blue, Yellow, Black},
+		Copies:            2,
+		Jokers:            2,
+		MinMeldSize:       3,
+		InitialMeldPoints: 30,
+	}
+}
+
+// withDefaults は指定されていない（0 の）項目を項目ごとに公式ルールの値で埋める。
+// 何度適用しても同じになるよう、ジョーカーの枚数と初手の点数の負の値（なし）はそのまま残す。
+func (r Rules) withDefaults() Rules {
+	d := DefaultRules()
+	if r.MinNumber == 0 {
+		r.MinNumber = d.MinNumber
+	}
+	if r.MaxNumber == 0 {
+		r.MaxNumber = d.MaxNumber
+	}
+	if len(r.Colors) == 0 {
+		r.Colors = d.Colors
+	}
+	if r.Copies == 0 {
+		r.Copies = d.Copies
+	}
+	if r.Jokers == 0 {
+		r.Jokers = d.Jokers
+	}
+	if r.MinMeldSize == 0 {
+		r.MinMeldSize = d.MinMeldSize
+	}
+	if r.InitialMeldPoints == 0 {
+		r.InitialMeldPoints = d.InitialMeldPoints
+	}
+	return r
+}
+
+// Validate は既定値で埋めたルールが矛盾していないかを確かめる。
+// 数字の最小値が1未満、最大値が最小値未満、同じ色の重複、同じタイルの枚数が負、メルドの最小枚数が1未満なら誤りを返す。
+func (r Rules) Validate() error {
+	r = r.withDefaults()
+	if r.MinNumber < 1 {
+		return fmt.Errorf("invalid rules: min number %d is less than 1", r.MinNumber)
+	}
+	if r.MaxNumber < r.MinNumber {
+		return fmt.Errorf("invalid rules: max number %d is less than min number %d", r.MaxNumber, r.MinNumber)
+	}
+	for i, c := range r.Colors {
+		if slices.Contains(r.Colors[:i], c) {
+			return fmt.Errorf("invalid rules: duplicate color %s", c)
+		}
+	}
+	if r.Copies < 0 {
+		return fmt.Errorf("invalid rules: copies %d is negative", r.Copies)
+	}
+	if r.MinMeldSize < 1 {
+		return fmt.Errorf("invalid rules: min meld size %d is less than 1", r.MinMeldSize)
+	}
+	return nil
+}
+
+// jokerCount はデッキのジョーカーの枚数を返す。Jokers が負（なし）なら 0
+func (r Rules) jokerCount() int {
+	return max(r.Jokers, 0)
+}
+
+// colorCount は色の種類の数（グループの最大枚数）を返す
+func (r Rules) colorCount() int {
+	colors := slices.Clone(r.Colors)
+	slices.Sort(colors)
+	return len(slices.Compact(colors))
+}
+
+// numberCount は数字の種類の数を返す
+func (r Rules) numberCount() int {
+	return int(r.MaxNumber - r.MinNumber + 1)
+}
+
+// runNumber は start から k 個先の数字を返す。折り返しありなら範囲の先頭に戻る。
+func (r Rules) runNumber(start TileNumber, k int) TileNumber {
+	n := int(start-r.MinNumber) + k
+	if r.WrapRuns {
+		n %= r.numberCount()
+	}
+	return r.MinNumber + TileNumber(n)
+}
+
+// runStartPoints は start から始まる長さ n のランの数字の合計を返す
+func (r Rules) runStartPoints(start TileNumber, n int) int {
+	points := 0
+	for k := 0; k < n; k++ {
+		points += int(r.runNumber(start, k))
+	}
+	return points
+}
+
+// validTile はタイルがこのルールのデッキに含まれるかを返す
+func (r Rules) validTile(t Tile) bool {
+	if t.IsJoker {
+		return r.jokerCount() > 0
+	}
+	return slices.Contains(r.Colors, t.Color) && t.Number >= r.MinNumber && t.Number <= r.MaxNumber
+}
+
+// Engine は Exact Cover の探索エンジン
+type Engine int
+
+const (
+	// EngineBacktrack は先頭の未使用タイルから分岐する単純なバックトラッキング
+	EngineBacktrack Engine = iota
+	// EngineDLX は Dancing Links による Algorithm X
+	EngineDLX
+)
+
+func (e Engine) String() string {
+	switch e {
+	case EngineBacktrack:
+		return "backtrack"
+	case EngineDLX:
+		return "dlx"
+	default:
+		return "unknown"
+	}
+}
+
+// ParseEngine はエンジン名から Engine を返す
+func ParseEngine(s string) (Engine, error) {
+	for _, e := range []Engine{EngineBacktrack, EngineDLX} {
+		if e.String() == s {
+			return e, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown engine: %s", s)
+}
+
+// Options は探索の設定
+type Options struct {
+	Rules  Rules
+	Engine Engine
+	// Stats が nil でなければ、詰み判定の探索の統計を書き込む
+	Stats *Stats
+	// Workers は詰み判定の探索に使うゴルーチンの数。1以下なら並列にしない
+	Workers int
+	// Deterministic が true なら、並列に探索してもゴルーチンの数や実行のたびに同じ解を返す
+	Deterministic bool
+	// Memo は詰み判定や最も多く手札を出せる手の探索で、解のなかった部分局面を覚える表の大きさ（エントリ数）。
+	// 0 なら DefaultMemoSize、負なら覚えない。並列に探索するときは枝ごとに表を持つ
+	Memo int
+}
+
+// memoSize は Memo の既定値を埋めた表の大きさを返す
+func (o Options) memoSize() int {
+	switch {
+	case o.Memo == 0:
+		return DefaultMemoSize
+	case o.Memo < 0:
+		return 0
+	}
+	return o.Memo
+}
+
+// DefaultOptions は公式ルールで探索する設定を返す
+func DefaultOptions() Options {
+	return Options{
+		Rules: DefaultRules(),
+	}
+}
@@ -0,0 +1,207 @@
+package rummikub
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+)
+
+func TestMeld_IsValidWith(t *testing.T) {
+	wrap := DefaultRules()
+	wrap.WrapRuns = true
+	large := DefaultRules()
+	large.MaxNumber = 15
+	twoColors := DefaultRules()
+	twoColors.Colors = []Color{Red, Blue}
+	twoColors.MinMeldSize = 2
+
+	tests := []struct {
+		name  string
+		meld  Meld
+		rules Rules
+		want  bool
+	}{
+		{"no wrap by default", Meld{R12, R13, R1}, DefaultRules(), false},
+		{"wrap run", Meld{R12, R13, R1}, wrap, true},
+		{"wrap run with joker", Meld{R13, JK, R2}, wrap, true},
+		{"wrap run too long", Meld{R1, R2, R3, R4, R5, R6, R7, R8, R9, R10, R11, R12, R13, JK}, wrap, false},
+		{"large number run", Meld{R13, NewTile(Red, 14), NewTile(Red, 15)}, large, true},
+		{"out of range", Meld{R13, NewTile(Red, 14), NewTile(Red, 15)}, DefaultRules(), false},
+		{"two tile run", Meld{R1, R2}, twoColors, true},
+		{"two tile group", Meld{R7, B7}, twoColors, true},
+		{"color not in deck", Meld{R7, Y7}, twoColors, false},
+		{"group larger than colors", Meld{R7, B7, JK}, twoColors, false},
+		{"duplicate colors count once", Meld{R7, B7, JK}, Rules{Colors: []Color{Red, Red, Blue}}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.meld.IsValidWith(tt.rules); got != tt.want {
+				t.Errorf("%s.IsValidWith() = %v, want %v", tt.meld, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSolveCheckmateWithOptions_Rules(t *testing.T) {
+	board := Board{Melds: []Meld{{R11, R12, R13}}}
+	hand := Hand{Tiles: []Tile{R1, R2}}
+
+	if ok, _ := SolveCheckmate(board, hand); ok {
+		t.Error("Expected no checkmate without wrapping runs")
+	}
+
+	opts := DefaultOptions()
+	opts.Rules.WrapRuns = true
+	gs := &GameState{Board: board, Hand: hand, Opened: true}
+	ok, solution := SolveCheckmateWithOptions(gs, opts)
+	if !ok {
+		t.Fatal("Expected checkmate with wrapping runs")
+	}
+	checkSolution(t, gs, opts.Rules, solution)
+
+	if ok, _ := SolveCheckmateDPWithRules(board, hand, opts.Rules); !ok {
+		t.Error("SolveCheckmateDPWithRules() expected checkmate with wrapping runs")
+	}
+}
+
+func TestSolveCheckmateDPWithRules_LargeDeck(t *testing.T) {
+	r := DefaultRules()
+	r.MaxNumber = 15
+	r.Colors = []Color{Red, Blue, Yellow}
+	board := Board{Melds: []Meld{{R12, R13, NewTile(Red, 14)}}}
+	hand := Hand{Tiles: []Tile{NewTile(Red, 15), NewTile(Blue, 15), NewTile(Yellow, 15), JK}}
+
+	for _, solve := range []func(Board, Hand) (bool, []Meld){
+		func(b Board, h Hand) (bool, []Meld) { return SolveCheckmateDPWithRules(b, h, r) },
+		func(b Board, h Hand) (bool, []Meld) {
+			return SolveCheckmateWithOptions(&GameState{Board: b, Hand: h, Opened: true}, Options{Rules: r})
+		},
+	} {
+		ok, solution := solve(board, hand)
+		if !ok {
+			t.Fatal("Expected checkmate with numbers up to 15")
+		}
+		checkSolution(t, &GameState{Board: board, Hand: hand, Opened: true}, r, solution)
+	}
+
+	if ok, _ := SolveCheckmateDP(board, hand); ok {
+		t.Error("Expected no checkmate with the default deck")
+	}
+}
+
+func TestParseGameStateWithRules(t *testing.T) {
+	r := DefaultRules()
+	r.MaxNumber = 15
+	r.Jokers = 1
+
+	gs, err := ParseGameStateWithRules([]byte(`{"board": [["R13", "R14", "R15"]], "hand": ["JK"]}`), r)
+	if err != nil {
+		t.Fatalf("ParseGameStateWithRules() error = %v", err)
+	}
+	if got := gs.Board.Melds[0][2]; got.Number != 15 || got.Color != Red {
+		t.Errorf("Board.Melds[0][2] = %s, want R15", got)
+	}
+
+	for _, data := range []string{
+		`{"board": [], "hand": ["R16"]}`,
+		`{"board": [], "hand": ["JK", "JK"]}`,
+		`{"board": [["R1", "R2", "R3"]], "hand": ["R1", "R1"]}`,
+	} {
+		if _, err := ParseGameStateWithRules([]byte(data), r); err == nil {
+			t.Errorf("ParseGameStateWithRules(%s) expected error, got nil", data)
+		}
+	}
+
+	if _, err := ParseGameState([]byte(`{"board": [], "hand": ["R14"]}`)); err == nil {
+		t.Error("ParseGameState() expected error for R14 with the default deck")
+	}
+}
+
+func TestRules_withDefaults(t *testing.T) {
+	with := func(f func(*Rules)) Rules {
+		r := DefaultRules()
+		f(&r)
+		return r
+	}
+	tests := []struct {
+		name  string
+		rules Rules
+		want  Rules
+	}{
+		{"zero rules", Rules{}, DefaultRules()},
+		{"jokers with other fields set", Rules{MaxNumber: 15, Copies: 3}, with(func(r *Rules) { r.MaxNumber, r.Copies = 15, 3 })},
+		{"min number alone", Rules{MinNumber: 3}, with(func(r *Rules) { r.MinNumber = 3 })},
+		{"max number alone", Rules{MaxNumber: 9}, with(func(r *Rules) { r.MaxNumber = 9 })},
+		{"initial meld points with other fields set", Rules{MinMeldSize: 2}, with(func(r *Rules) { r.MinMeldSize = 2 })},
+		{"no jokers", Rules{Jokers: -1}, with(func(r *Rules) { r.Jokers = -1 })},
+		{"no initial meld points", Rules{InitialMeldPoints: -1}, with(func(r *Rules) { r.InitialMeldPoints = -1 })},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.rules.withDefaults()
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("withDefaults() = %+v, want %+v", got, tt.want)
+			}
+			if again := got.withDefaults(); fmt.Sprint(again) != fmt.Sprint(got) {
+				t.Errorf("withDefaults() twice = %+v, want %+v", again, got)
+			}
+		})
+	}
+
+	if (Rules{Jokers: -1}).withDefaults().validTile(JK) {
+		t.Error("validTile(JK) = true with Jokers < 0")
+	}
+	gs := &GameState{Board: Board{Melds: []Meld{{R1, R2, R3}}}, Hand: Hand{Tiles: []Tile{R4}}, Opened: true}
+	if ok, _ := SolveCheckmateWithOptions(gs, Options{Rules: Rules{Jokers: -1}}); !ok {
+		t.Error("Expected checkmate without jokers")
+	}
+}
+
+func TestRules_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		rules   Rules
+		wantErr bool
+	}{
+		{"default", DefaultRules(), false},
+		{"zero rules", Rules{}, false},
+		{"min number below 1", Rules{MinNumber: -1}, true},
+		{"max number below min number", Rules{MinNumber: 5, MaxNumber: 4}, true},
+		{"duplicate colors", Rules{Colors: []Color{Red, Red, Blue}}, true},
+		{"negative copies", Rules{Copies: -1}, true},
+		{"negative min meld size", Rules{MinMeldSize: -1}, true},
+		{"min meld size 1", Rules{MinMeldSize: 1}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.rules.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	if _, err := ParsePositionWithRules("/ R1 R2 R3", Rules{Colors: []Color{Red, Red}}); err == nil {
+		t.Error("ParsePositionWithRules() expected error for duplicate colors")
+	}
+	if _, err := ParseGameStateWithRules([]byte(`{"board": [], "hand": ["R1"]}`), Rules{MinNumber: -1}); err == nil {
+		t.Error("ParseGameStateWithRules() expected error for min number below 1")
+	}
+	if _, err := ParsePositionWithRules("/ JK", Rules{Jokers: -1}); err == nil {
+		t.Error("ParsePositionWithRules() expected error for a joker without jokers")
+	}
+}
+
+func TestParseColors(t *testing.T) {
+	colors, err := ParseColors("RBK")
+	if err != nil || !slices.Equal(colors, []Color{Red, Blue, Black}) {
+		t.Errorf("ParseColors(RBK) = %v, %v", colors, err)
+	}
+	for _, s := range []string{"RRB", "RX"} {
+		if _, err := ParseColors(s); err == nil {
+			t.Errorf("ParseColors(%q) expected error, got nil", s)
+		}
+	}
+}
@@ -0,0 +1,340 @@
+package rummikub
+
+import (
+	"context"
+	"iter"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Solutions は詰みの解をすべて列挙する。
+// 同じ種類のタイルやジョーカーを入れ替えただけの解は1つにまとめる。
+func Solutions(board Board, hand Hand) iter.Seq[[]Meld] {
+	gs := &GameState{Board: board, Hand: hand, Opened: true}
+	return SolutionsWithOptions(gs, DefaultOptions())
+}
+
+// SolutionsWithOptions はゲームの状態と探索設定を指定して Solutions を行う
+func SolutionsWithOptions(gs *GameState, opts Options) iter.Seq[[]Meld] {
+	return func(yield func([]Meld) bool) {
+		for solution := range SolutionsContext(context.Background(), gs, opts) {
+			if !yield(solution) {
+				return
+			}
+		}
+	}
+}
+
+// SolutionsContext は ctx の期限とキャンセルに従って SolutionsWithOptions を行う。
+// 解は誤りを nil として渡し、探索を打ち切ったときは最後に nil と ctx.Err() を渡す。
+func SolutionsContext(ctx context.Context, gs *GameState, opts Options) iter.Seq2[[]Meld, error] {
+	return func(yield func([]Meld, error) bool) {
+		done := false
+		err := enumerateCheckmates(ctx, gs, opts, func(solution [][]Tile) bool {
+			// yield が false を返したら探索を打ち切る
+			done = !yield(toMelds(arrangeSolution(solution, gs.Board, opts.Rules.withDefaults())), nil)
+			return done
+		})
+		if err != nil && !done {
+			yield(nil, err)
+		}
+	}
+}
+
+// CountSolutions は詰みの解の数を数える。
+// Solutions と同じく、タイルの入れ替えだけの違いは区別しない。
+func CountSolutions(board Board, hand Hand) int {
+	gs := &GameState{Board: board, Hand: hand, Opened: true}
+	return CountSolutionsWithOptions(gs, DefaultOptions())
+}
+
+// CountSolutionsWithOptions はゲームの状態と探索設定を指定して CountSolutions を行う。
+// 解を Meld に変換せず、探索しながら数える。
+func CountSolutionsWithOptions(gs *GameState, opts Options) int {
+	n, _ := CountSolutionsContext(context.Background(), gs, opts)
+	return n
+}
+
+// CountSolutionsContext は ctx の期限とキャンセルに従って CountSolutionsWithOptions を行う。
+// 打ち切ったときは ctx.Err() を返し、このとき数はそれまでに見つけた解の数になる。
+func CountSolutionsContext(ctx context.Context, gs *GameState, opts Options) (int, error) {
+	n := 0
+	err := enumerateCheckmates(ctx, gs, opts, func([][]Tile) bool {
+		n++
+		return false
+	})
+	return n, err
+}
+
+// enumerateCheckmates は詰みの解をすべて visit に渡す。visit が true を返したら探索を打ち切る。
+// 同じ種類のタイルやジョーカーを入れ替えただけの解は、ルール上の判定を満たす入れ替えがあれば1つだけ渡す。
+// 渡す解は動かさない場のメルドを含み、探索が終わると書き換わるので、残すときは写す。
+func enumerateCheckmates(ctx context.Context, gs *GameState, opts Options, visit func(solution [][]Tile) bool) error {
+	start := time.Now()
+	opts.Rules = opts.Rules.withDefaults()
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	allTiles := collectTiles(gs.Board, gs.Hand)
+	check := newRuleCheck(gs, opts.Rules, allTiles)
+	tiles, fixed := coverTiles(gs, opts.Rules, allTiles)
+	withFixed := func(solution [][]Tile) [][]Tile { return append(slices.Clip(fixed), solution...) }
+
+	// 探索では同じ種類のタイルを区別しないので、ルール上の判定があれば見つけた被覆のコピーを入れ替えて確かめる
+	visitCover := func(solution [][]Tile) bool {
+		return visit(withFixed(preferOrigins(solution, gs.Board, allTiles, Tile.Notation)))
+	}
+	if check != nil {
+		key := copyKey(gs.Board, allTiles, true)
+		r := newRealizer(tiles, key)
+		visitCover = func(solution [][]Tile) bool {
+			realized, ok := r.realize(solution, func(s [][]Tile) bool { return check.accept(withFixed(s)) })
+			return ok && visit(withFixed(preferOrigins(realized, gs.Board, allTiles, key)))
+		}
+	}
+
+	candidates := GenerateAllCandidatesWithRules(tiles, opts.Rules)
+	mon := newMonitor(ctx)
+	mon.memoSize = opts.memoSize()
+	mon.stats.Candidates = len(candidates)
+	infos := buildCandidateInfos(tiles, candidates)
+	if precheck(tiles, infos) == nil {
+		enumerateCover(opts.Engine, tiles, infos, visitCover, mon)
+	}
+	if opts.Stats != nil {
+		mon.stats.Elapsed = time.Since(start)
+		*opts.Stats = mon.stats
+	}
+	return mon.err
+}
+
+// enumerateCover は指定されたエンジンで被覆をすべて visit に渡す。visit が true を返したら探索を打ち切る。
+// 同じ種類のタイル同士を入れ替えただけの被覆は1つだけ渡す。
+// 解のなかった部分局面は、visit の結果によらず被覆がなかった部分局面だけを覚える。
+func enumerateCover(engine Engine, tiles []Tile, infos []candidateInfo, visit func(solution [][]Tile) bool, mon *monitor) {
+	copies := copyOrder(tiles, Tile.Notation)
+	enum := newEnumeration(tiles, infos, copies, visit)
+	var memo *failMemo
+	hashes := mon.memoHashes(tiles, infos, nil)
+	if hashes != nil {
+		memo = newFailMemo(mon.memoSize)
+	}
+
+	if engine == EngineDLX {
+		d := newDLX(len(tiles), infos, copies, nil, mon)
+		d.memo, d.hashes, d.enum = memo, hashes, enum
+		d.search()
+		return
+	}
+	s := newCoverSearch(tiles, infos, copies, nil, mon)
+	s.memo, s.hashes, s.enum = memo, hashes, enum
+	s.backtrack()
+}
+
+// enumeration は被覆を列挙するときに、同じ種類のタイルを入れ替えただけの被覆を一度だけ訪れるための状態。
+//
+// 候補は同じ種類のコピーのうち小さい位置のものから使い（lowestCopies）、種類の組に順位を付ける。
+// あるタイルで分岐して候補を選んだら、同じ種類のタイルを含む候補はそれ以降、その候補以上の順位のものだけを選ぶ。
+// 被覆のメルドの組ごとに、分岐中のタイルを含む残りのメルドのうち順位が最小のものを選んでいく道筋だけが
+// この条件を満たすので、どの被覆もちょうど一度だけ訪れる。
+type enumeration struct {
+	visit   func(solution [][]Tile) bool
+	kinds   []int // タイルごとの種類（同じ種類のコピーのうち最も小さい位置）
+	rank    []int // 候補ごとの、タイルの種類の組での順位
+	bound   []int // 種類ごとの、その種類のタイルで分岐して最後に選んだ候補の順位
+	covers  int   // 見つけた被覆の数
+	skipped int   // 順位の条件で飛ばした候補の数
+}
+
+// newEnumeration は tiles の被覆を列挙する状態を返す。copies は copyOrder(tiles, Tile.Notation) の結果
+func newEnumeration(tiles []Tile, candidates []candidateInfo, copies []int, visit func(solution [][]Tile) bool) *enumeration {
+	e := &enumeration{
+		visit: visit,
+		kinds: make([]int, len(tiles)),
+		rank:  make([]int, len(candidates)),
+		bound: make([]int, len(tiles)),
+	}
+	for i := range tiles {
+		e.kinds[i] = i
+		if copies[i] >= 0 {
+			e.kinds[i] = e.kinds[copies[i]]
+		}
+		e.bound[i] = -1
+	}
+
+	// 候補をタイルの種類の整列した並びで順に並べ、同じ並びの候補には同じ順位を付ける
+	signatures := make([][]int, len(candidates))
+	order := make([]int, len(candidates))
+	for c, candidate := range candidates {
+		signatures[c] = make([]int, len(candidate.indices))
+		for k, idx := range candidate.indices {
+			signatures[c][k] = e.kinds[idx]
+		}
+		slices.Sort(signatures[c])
+		order[c] = c
+	}
+	slices.SortFunc(order, func(a, b int) int { return slices.Compare(signatures[a], signatures[b]) })
+	rank := 0
+	for k, c := range order {
+		if k > 0 && slices.Compare(signatures[order[k-1]], signatures[c]) != 0 {
+			rank++
+		}
+		e.rank[c] = rank
+	}
+	return e
+}
+
+// leaf は被覆を1つ数えて visit に渡し、探索を打ち切るかを返す
+func (e *enumeration) leaf(solution [][]Tile) bool {
+	e.covers++
+	return e.visit(solution)
+}
+
+// allows は候補 c（candidate）を選んでよいかを返す。nil なら常に選んでよい
+func (e *enumeration) allows(c int, candidate candidateInfo) bool {
+	if e == nil {
+		return true
+	}
+	for _, idx := range candidate.indices {
+		if e.rank[c] < e.bound[e.kinds[idx]] {
+			e.skipped++
+			return false
+		}
+	}
+	return true
+}
+
+// choose はタイル branch で分岐して候補 c を選んだことを記録し、restore に渡す元の値を返す
+func (e *enumeration) choose(c, branch int) int {
+	if e == nil {
+		return 0
+	}
+	k := e.kinds[branch]
+	prev := e.bound[k]
+	e.bound[k] = e.rank[c]
+	return prev
+}
+
+// restore は choose を取り消す
+func (e *enumeration) restore(branch, prev int) {
+	if e != nil {
+		e.bound[e.kinds[branch]] = prev
+	}
+}
+
+// mark は failed に渡す、その時点までの被覆と飛ばした候補の数を返す
+func (e *enumeration) mark() [2]int {
+	if e == nil {
+		return [2]int{}
+	}
+	return [2]int{e.covers, e.skipped}
+}
+
+// failed は mark の後に被覆が見つからず、順位の条件で飛ばした候補もないか（部分局面に被覆がないと言えるか）を返す。
+// nil なら常に true（最初の解で止まる探索は、被覆が見つかれば戻ってこない）
+func (e *enumeration) failed(mark [2]int) bool {
+	return e == nil || e.mark() == mark
+}
+
+// realizer は同じ種類のタイルを区別せずに見つけた被覆に、ルール上の判定を満たすタイルを割り当てる
+type realizer struct {
+	index   map[TileID]int
+	kinds   []int // タイルごとの種類（表記が同じコピーのうち最も小さい位置）
+	classes []int // タイルごとの、key が同じコピーのうち最も小さい位置
+}
+
+// newRealizer は tiles の被覆にタイルを割り当てる realizer を返す。
+// key が同じタイルは判定を変えずに入れ替えられるものとする。
+func newRealizer(tiles []Tile, key func(Tile) string) *realizer {
+	r := &realizer{
+		index:   make(map[TileID]int),
+		kinds:   make([]int, len(tiles)),
+		classes: make([]int, len(tiles)),
+	}
+	kindCopies := copyOrder(tiles, Tile.Notation)
+	classCopies := copyOrder(tiles, key)
+	for i, tile := range tiles {
+		r.index[tile.ID] = i
+		r.kinds[i], r.classes[i] = i, i
+		if kindCopies[i] >= 0 {
+			r.kinds[i] = r.kinds[kindCopies[i]]
+		}
+		if classCopies[i] >= 0 {
+			r.classes[i] = r.classes[classCopies[i]]
+		}
+	}
+	return r
+}
+
+// realize は被覆のタイルを同じ種類のタイルの間で入れ替え、accept が採用する割り当てを探す。
+// key が異なるタイルの入れ替えだけを試す。
+func (r *realizer) realize(solution [][]Tile, accept acceptFunc) ([][]Tile, bool) {
+	type slot struct{ meld, pos int }
+	var kinds []int                 // 被覆に現れた種類
+	slots := make(map[int][]slot)   // 種類ごとの、被覆の中の位置
+	pools := make(map[int][][]Tile) // 種類ごとの、key が同じタイルの組
+	for i, meld := range solution {
+		for k, tile := range meld {
+			idx := r.index[tile.ID]
+			kind := r.kinds[idx]
+			if _, ok := slots[kind]; !ok {
+				kinds = append(kinds, kind)
+			}
+			slots[kind] = append(slots[kind], slot{i, k})
+			pool := slices.IndexFunc(pools[kind], func(p []Tile) bool { return r.classes[r.index[p[0].ID]] == r.classes[idx] })
+			if pool < 0 {
+				pools[kind] = append(pools[kind], nil)
+				pool = len(pools[kind]) - 1
+			}
+			pools[kind][pool] = append(pools[kind][pool], tile)
+		}
+	}
+
+	result := make([][]Tile, len(solution))
+	for i, meld := range solution {
+		result[i] = slices.Clone(meld)
+	}
+	next := make(map[int][]int) // 種類ごとの、各組で次に使うタイル
+	for _, kind := range kinds {
+		next[kind] = make([]int, len(pools[kind]))
+	}
+	var search func(k, s int) bool
+	search = func(k, s int) bool {
+		if k == len(kinds) {
+			return accept(result)
+		}
+		kind := kinds[k]
+		if s == len(slots[kind]) {
+			return search(k+1, 0)
+		}
+		for p, pool := range pools[kind] {
+			if next[kind][p] == len(pool) {
+				continue
+			}
+			result[slots[kind][s].meld][slots[kind][s].pos] = pool[next[kind][p]]
+			next[kind][p]++
+			ok := search(k, s+1)
+			next[kind][p]--
+			if ok {
+				return true
+			}
+		}
+		return false
+	}
+	if search(0, 0) {
+		return result, true
+	}
+	return nil, false
+}
+
+// meldKey はメルドのタイルの種類を整列して連結したキーを返す
+func meldKey(tiles []Tile) string {
+	notations := make([]string, len(tiles))
+	for i, tile := range tiles {
+		notations[i] = tile.Notation()
+	}
+	slices.Sort(notations)
+	return strings.Join(notations, " ")
+}
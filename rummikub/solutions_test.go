@@ -0,0 +1,139 @@
+package rummikub
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestSolutions_Distinct(t *testing.T) {
+	// [R1 R2 R3] [B1 B2 B3] [Y1 Y2 Y3] と 3つのグループの2通り
+	board := Board{Melds: []Meld{
+		{R1, R2, R3},
+		{B1, B2, B3},
+	}}
+	hand := Hand{Tiles: []Tile{Y1, Y2, Y3}}
+
+	seen := make(map[string]bool)
+	count := 0
+	for solution := range Solutions(board, hand) {
+		count++
+		checkSolutionTiles(t, board, hand, solution)
+
+		tiles := make([][]Tile, len(solution))
+		for i, meld := range solution {
+			tiles[i] = meld
+		}
+		key := solutionKey(tiles)
+		if seen[key] {
+			t.Errorf("Duplicate solution: %v", solution)
+		}
+		seen[key] = true
+	}
+	if count != 2 {
+		t.Errorf("Expected 2 solutions, got %d", count)
+	}
+	if got := CountSolutions(board, hand); got != count {
+		t.Errorf("CountSolutions() = %d, want %d", got, count)
+	}
+}
+
+func TestSolutions_JokersCollapsed(t *testing.T) {
+	// JK=R1 と JK=R5 の解釈は同じタイルの組み合わせなので1つにまとめる
+	hand := Hand{Tiles: []Tile{R2, R3, R4, JK}}
+	if got := CountSolutions(Board{}, hand); got != 1 {
+		t.Errorf("CountSolutions() = %d, want 1", got)
+	}
+}
+
+func TestSolutions_NoSolution(t *testing.T) {
+	board := Board{Melds: []Meld{{R1, R2, R3}}}
+	hand := Hand{Tiles: []Tile{B5, B6, Y7}}
+
+	for solution := range Solutions(board, hand) {
+		t.Errorf("Unexpected solution: %v", solution)
+	}
+	if got := CountSolutions(board, hand); got != 0 {
+		t.Errorf("CountSolutions() = %d, want 0", got)
+	}
+}
+
+func TestSolutions_StopEarly(t *testing.T) {
+	board := Board{Melds: []Meld{
+		{R1, R2, R3},
+		{B1, B2, B3},
+	}}
+	hand := Hand{Tiles: []Tile{Y1, Y2, Y3}}
+
+	count := 0
+	for range Solutions(board, hand) {
+		count++
+		break
+	}
+	if count != 1 {
+		t.Errorf("Expected to stop after 1 solution, got %d", count)
+	}
+}
+
+func TestSolutions_EnginesAgree(t *testing.T) {
+	for _, p := range testPositions {
+		gs := &GameState{Board: p.board, Hand: p.hand, Opened: true}
+		counts := make(map[Engine]int)
+		for _, engine := range []Engine{EngineBacktrack, EngineDLX} {
+			opts := DefaultOptions()
+			opts.Engine = engine
+			counts[engine] = CountSolutionsWithOptions(gs, opts)
+		}
+		if counts[EngineBacktrack] != counts[EngineDLX] {
+			t.Errorf("%s: backtrack counted %d, dlx counted %d", p.name, counts[EngineBacktrack], counts[EngineDLX])
+		}
+	}
+}
+
+func TestSolutions_Generated(t *testing.T) {
+	// 同じタイルが2枚ずつある局面でも、探索しながら一度ずつ数えた解の数が、列挙した解の異なる組の数と一致する。
+	// 場のジョーカーや初手のルールがあれば、ルールを満たすタイルの割り当てのある解だけを数える
+	for seed := uint64(1); seed <= 10; seed++ {
+		board, hand := generatePositionWithCopies(seed, 15, 2, 2)
+		jokerBoard := Board{Melds: append(slices.Clone(board.Melds), Meld{R5, JK, R7})}
+		for _, gs := range []*GameState{
+			{Board: board, Hand: hand, Opened: true},
+			{Board: board, Hand: hand},
+			{Board: jokerBoard, Hand: Hand{Tiles: append(slices.Clone(hand.Tiles), R6)}, Opened: true},
+		} {
+			for _, engine := range []Engine{EngineBacktrack, EngineDLX} {
+				opts := DefaultOptions()
+				opts.Engine = engine
+				seen := make(map[string]bool)
+				for solution := range SolutionsWithOptions(gs, opts) {
+					tiles := make([][]Tile, len(solution))
+					for i, meld := range solution {
+						tiles[i] = meld
+					}
+					key := solutionKey(tiles)
+					if seen[key] {
+						t.Errorf("seed %d %s: duplicate solution %v", seed, engine, solution)
+					}
+					seen[key] = true
+					if err := VerifyWithRules(gs, solution, opts.Rules); err != nil {
+						t.Errorf("seed %d %s: VerifyWithRules(%v) = %v", seed, engine, solution, err)
+					}
+				}
+				if got := CountSolutionsWithOptions(gs, opts); got != len(seen) {
+					t.Errorf("seed %d %s: CountSolutionsWithOptions() = %d, want %d", seed, engine, got, len(seen))
+				}
+			}
+		}
+	}
+}
+
+// solutionKey はタイルの同一性（ID）を無視した解のキーを返す。
+// 各メルドのタイルと、メルドの並びをそれぞれ整列して連結する。
+func solutionKey(solution [][]Tile) string {
+	keys := make([]string, len(solution))
+	for i, tiles := range solution {
+		keys[i] = meldKey(tiles)
+	}
+	slices.Sort(keys)
+	return strings.Join(keys, "|")
+}
@@ -0,0 +1,788 @@
+package rummikub
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+)
+
+// GenerateAllCandidates は全ての候補セット（ラン・グループ）を公式ルールで生成する
+func GenerateAllCandidates(tiles []Tile) [][]Tile {
+	return GenerateAllCandidatesWithRules(tiles, DefaultRules())
+}
+
+// GenerateAllCandidatesWithRules は指定したルールで全ての候補セットを生成する。
+// 候補はルールごとに作ってあるメルドのカタログ（catalogFor）から、tiles の枚数で作れるものを選ぶ。
+// 同じタイルの物理的なコピーが複数あるときは、各形についてどのコピーを使うかの組み合わせをすべて候補にする。
+// メルドの有効性はタイルの種類だけで決まるので、どの解もいずれかの候補の組み合わせとして表せる。
+func GenerateAllCandidatesWithRules(tiles []Tile, r Rules) [][]Tile {
+	r = r.withDefaults()
+	return catalogFor(r).candidates(tiles)
+}
+
+// combinations は items から k 個を選ぶ組み合わせをすべて返す
+func combinations(items []int, k int) [][]int {
+	if k == 0 {
+		return [][]int{nil}
+	}
+	var result [][]int
+	for i := range items {
+		for _, rest := range combinations(items[i+1:], k-1) {
+			result = append(result, append([]int{items[i]}, rest...))
+		}
+	}
+	return result
+}
+
+// getCombinations はn個からr個を選ぶ組み合わせを生成
+func getCombinations(tiles []Tile, r int) [][]Tile {
+	var result [][]Tile
+	n := len(tiles)
+
+	var backtrack func(start int, current []Tile)
+	backtrack = func(start int, current []Tile) {
+		if len(current) == r {
+			combo := make([]Tile, r)
+			copy(combo, current)
+			result = append(result, combo)
+			return
+		}
+		for i := start; i < n; i++ {
+			backtrack(i+1, append(current, tiles[i]))
+		}
+	}
+
+	backtrack(0, []Tile{})
+	return result
+}
+
+// candidateInfo は候補セットの情報
+type candidateInfo struct {
+	tiles   []Tile
+	indices []int
+	mask    tileSet // indices の集合
+}
+
+// SolveCheckmate は詰み判定を行い、解があれば解を返す。
+// 初手（30点ルール）は済んでいるものとして標準ルールで判定する。
+func SolveCheckmate(board Board, hand Hand) (bool, []Meld) {
+	gs := &GameState{Board: board, Hand: hand, Opened: true}
+	return SolveCheckmateWithOptions(gs, DefaultOptions())
+}
+
+// SolveCheckmateWithOptions はゲームの状態と探索設定を指定して詰み判定を行う
+func SolveCheckmateWithOptions(gs *GameState, opts Options) (bool, []Meld) {
+	return findCheckmate(gs, opts, nil)
+}
+
+// findCheckmate は詰みの解を探し、accept が採用した最初の解を返す。
+// accept には動かさない場のメルドも含めた最終的な盤面が渡される。
+func findCheckmate(gs *GameState, opts Options, accept acceptFunc) (bool, []Meld) {
+	ok, solution, _ := findCheckmateContext(context.Background(), gs, opts, accept)
+	return ok, solution
+}
+
+// findCheckmateContext は ctx の期限とキャンセルに従って findCheckmate を行う
+func findCheckmateContext(ctx context.Context, gs *GameState, opts Options, accept acceptFunc) (bool, []Meld, error) {
+	start := time.Now()
+	opts.Rules = opts.Rules.withDefaults()
+	if err := ctx.Err(); err != nil {
+		return false, nil, err
+	}
+
+	// 全タイルを収集し、IDを付与
+	allTiles := collectTiles(gs.Board, gs.Hand)
+	check := newRuleCheck(gs, opts.Rules, allTiles)
+	tiles, fixed := coverTiles(gs, opts.Rules, allTiles)
+
+	// ルール上の判定がなければ、すべてのタイルを覆うので同じ種類のタイルはどれも交換できる。
+	// 判定があっても、判定が見分けないコピー同士は交換できる（copyKey）。
+	// 探索ではそれらのコピーを区別せず、見つけた解のコピーをなるべく元のメルドに合わせてから使う。
+	key := Tile.Notation
+	if check != nil {
+		key = copyKey(gs.Board, allTiles, true)
+	}
+	relabel := func(solution [][]Tile) [][]Tile { return preferOrigins(solution, gs.Board, allTiles, key) }
+
+	// ルール上の判定はコピーを入れ替えても変わらないので、合わせたコピーは呼び出し元の判定にだけ渡す
+	var combined acceptFunc
+	if check != nil || accept != nil {
+		combined = func(solution [][]Tile) bool {
+			if check != nil && !check.accept(append(slices.Clip(fixed), solution...)) {
+				return false
+			}
+			return accept == nil || accept(append(slices.Clip(fixed), relabel(solution)...))
+		}
+	}
+
+	// 全候補セットを生成し、Exact Coverで解を探索
+	candidates := check.allowed(GenerateAllCandidatesWithRules(tiles, opts.Rules))
+	infos := buildCandidateInfos(tiles, candidates)
+	mon := newMonitor(ctx)
+	mon.memoSize = opts.memoSize()
+	mon.stats.Candidates = len(candidates)
+	// 呼び出し元の判定は状態を表せないので、判定があるときは解のなかった部分局面を覚えない
+	if check != nil && accept == nil {
+		mon.memoRules = &memoRules{kind: key, state: check.state}
+	}
+	// 探索しなくても解がないと分かる局面（precheck）は探索せず、理由を統計に残す。
+	// 呼び出し元の判定は同時に呼べるとは限らないので、並列に探索するのは解を1つ探すときだけ
+	var solution [][]Tile
+	var ok bool
+	if mon.stats.Infeasible = precheck(tiles, infos); mon.stats.Infeasible == nil {
+		if opts.Workers > 1 && accept == nil {
+			solution, ok = parallelCover(opts.Engine, tiles, infos, copyOrder(tiles, key), combined, mon, opts.Workers, opts.Deterministic)
+		} else {
+			solution, ok = solveCover(opts.Engine, tiles, infos, copyOrder(tiles, key), combined, mon)
+		}
+	}
+	if opts.Stats != nil {
+		mon.stats.Elapsed = time.Since(start)
+		*opts.Stats = mon.stats
+	}
+	if mon.err != nil {
+		return false, nil, mon.err
+	}
+	if !ok {
+		return false, nil, nil
+	}
+	solution = relabel(solution)
+	return true, toMelds(arrangeSolution(append(fixed, solution...), gs.Board, opts.Rules)), nil
+}
+
+// coverTiles は探索で覆うタイルと、動かさない場のメルドを返す。
+// 初手前は場に触れられないので、手札だけで新しいメルドを作る。
+func coverTiles(gs *GameState, rules Rules, allTiles []Tile) ([]Tile, [][]Tile) {
+	if gs.Opened || rules.OpeningManipulation {
+		return allTiles, nil
+	}
+	var fixed [][]Tile
+	for _, meld := range boardMelds(gs.Board, allTiles) {
+		fixed = append(fixed, meld)
+	}
+	return allTiles[len(allTiles)-len(gs.Hand.Tiles):], fixed
+}
+
+// arrangeSolution は解のメルドのうち、手札のジョーカーだけを含むランを arrangeRun で並べ直す。
+// 表示する並び順とジョーカーの値が、初手の点数（PointsWith）と同じ解釈になる。
+// 場のジョーカーの値は元のメルドで決まっているので、場のジョーカーを含むメルドは並べ直さない。
+func arrangeSolution(solution [][]Tile, board Board, r Rules) [][]Tile {
+	boardCount := 0
+	for _, meld := range board.Melds {
+		boardCount += len(meld)
+	}
+	result := make([][]Tile, len(solution))
+	for i, tiles := range solution {
+		result[i] = tiles
+		handJoker, boardJoker := false, false
+		for _, tile := range tiles {
+			if tile.IsJoker {
+				if int(tile.ID) < boardCount {
+					boardJoker = true
+				} else {
+					handJoker = true
+				}
+			}
+		}
+		if handJoker && !boardJoker {
+			result[i] = Meld(tiles).arrangeRun(r)
+		}
+	}
+	return result
+}
+
+// toMelds は候補の組み合わせをMeldに変換する
+func toMelds(solution [][]Tile) []Meld {
+	var melds []Meld
+	for _, candidate := range solution {
+		melds = append(melds, Meld(candidate))
+	}
+	return melds
+}
+
+// boardMelds はIDを付与済みのタイルから、元の場のメルドを組み立て直す
+func boardMelds(board Board, allTiles []Tile) []Meld {
+	var melds []Meld
+	offset := 0
+	for _, meld := range board.Melds {
+		melds = append(melds, Meld(allTiles[offset:offset+len(meld)]))
+		offset += len(meld)
+	}
+	return melds
+}
+
+// ruleCheck はルール上の制約（初手・ジョーカーの取り出し）の判定
+type ruleCheck struct {
+	accept acceptFunc                 // 解を採用するか
+	allows func(tiles []Tile) bool    // 候補を解に使えるか。使えない候補は探索しない
+	state  func(tiles []Tile) memoKey // 候補を選んだときに判定の状態に加える値
+}
+
+// newRuleCheck はルール上の制約の判定を返す。制約がなければ nil を返す。
+// accept の結果は、copyKey(…, true) で交換可能なコピー同士を入れ替えても変わらず、
+// allows を満たす候補だけを使うなら、使ったタイルと、選んだ候補の state の和だけで決まる（memoRules）。
+func newRuleCheck(gs *GameState, rules Rules, allTiles []Tile) *ruleCheck {
+	boardCount := len(allTiles) - len(gs.Hand.Tiles)
+
+	var accepts []acceptFunc
+	var allows []func([]Tile) bool
+	var states []func([]Tile) memoKey
+	if !gs.Opened {
+		o := newOpeningRule(rules, boardCount)
+		accepts = append(accepts, o.accept)
+		states = append(states, o.state)
+	}
+	if !rules.FreeJokers {
+		if j := newJokerRule(gs.Board, allTiles, boardCount, rules); j != nil {
+			accepts = append(accepts, j.accept)
+			allows = append(allows, j.allows)
+			states = append(states, j.state)
+		}
+	}
+	if len(accepts) == 0 {
+		return nil
+	}
+
+	return &ruleCheck{
+		accept: func(solution [][]Tile) bool {
+			for _, accept := range accepts {
+				if !accept(solution) {
+					return false
+				}
+			}
+			return true
+		},
+		allows: func(tiles []Tile) bool {
+			for _, allow := range allows {
+				if !allow(tiles) {
+					return false
+				}
+			}
+			return true
+		},
+		state: func(tiles []Tile) memoKey {
+			var k memoKey
+			for _, state := range states {
+				k = k.plus(state(tiles))
+			}
+			return k
+		},
+	}
+}
+
+// allowed は candidates のうち、判定 c が解に使えるとする候補だけを返す。c が nil ならそのまま返す
+func (c *ruleCheck) allowed(candidates [][]Tile) [][]Tile {
+	if c == nil {
+		return candidates
+	}
+	return slices.DeleteFunc(candidates, func(tiles []Tile) bool { return !c.allows(tiles) })
+}
+
+// openingRule は初手の条件の判定。
+// 手札を1枚も出さない解と、手札だけで作ったメルドの合計点が足りている解を採用する。
+type openingRule struct {
+	rules      Rules
+	boardCount int
+	hashes     []memoKey // 手札を含むメルドと、手札だけのメルドの1点ごとに状態に加える値
+}
+
+// newOpeningRule は初手の条件の判定を返す
+func newOpeningRule(rules Rules, boardCount int) *openingRule {
+	return &openingRule{rules: rules, boardCount: boardCount, hashes: stateHashes(openingSalt, 2)}
+}
+
+// accept は解が初手の条件を満たすかを返す
+func (o *openingRule) accept(solution [][]Tile) bool {
+	played := false
+	points := 0
+	for _, tiles := range solution {
+		withHand, fromHand := o.split(tiles)
+		played = played || withHand
+		if fromHand {
+			points += Meld(tiles).PointsWith(o.rules)
+		}
+	}
+	return !played || points >= o.rules.InitialMeldPoints
+}
+
+// state は候補を選んだときに判定の状態に加える値を返す。
+// accept の結果は、手札を含むメルドの数と、手札だけのメルドの合計点だけで決まる。
+func (o *openingRule) state(tiles []Tile) memoKey {
+	var k memoKey
+	withHand, fromHand := o.split(tiles)
+	if withHand {
+		k = k.plus(o.hashes[0])
+	}
+	if fromHand {
+		k = k.plus(o.hashes[1].times(Meld(tiles).PointsWith(o.rules)))
+	}
+	return k
+}
+
+// split はタイルの組が手札のタイルを含むかと、手札のタイルだけでできているかを返す
+func (o *openingRule) split(tiles []Tile) (withHand, fromHand bool) {
+	fromHand = true
+	for _, tile := range tiles {
+		if int(tile.ID) < o.boardCount {
+			fromHand = false
+		} else {
+			withHand = true
+		}
+	}
+	return withHand, fromHand
+}
+
+// collectTiles は場と手札の全タイルを収集し、場→手札の順にIDを付与する
+func collectTiles(board Board, hand Hand) []Tile {
+	var allTiles []Tile
+	var id TileID = 0
+	for _, meld := range board.Melds {
+		for _, tile := range meld {
+			tile.ID = id
+			allTiles = append(allTiles, tile)
+			id++
+		}
+	}
+	for _, tile := range hand.Tiles {
+		tile.ID = id
+		allTiles = append(allTiles, tile)
+		id++
+	}
+	return allTiles
+}
+
+// solveCover は指定されたエンジンでExact Cover問題を解く。
+// copies は copyOrder の結果で、nil なら同じ種類のコピーを区別して探索する。
+// mon が探索を打ち切ったときは解なしとして返る。
+func solveCover(engine Engine, tiles []Tile, infos []candidateInfo, copies []int, accept acceptFunc, mon *monitor) ([][]Tile, bool) {
+	if engine == EngineDLX {
+		return dlxCover(tiles, infos, copies, accept, mon)
+	}
+	return exactCover(tiles, infos, copies, accept, mon)
+}
+
+// copyOrder は各タイルについて、key が同じ（交換しても解の意味が変わらない）タイルのうち
+// 1つ前のタイルの位置を返す。なければ -1。
+func copyOrder(tiles []Tile, key func(Tile) string) []int {
+	prev := make([]int, len(tiles))
+	last := make(map[string]int)
+	for i, tile := range tiles {
+		k := key(tile)
+		if j, ok := last[k]; ok {
+			prev[i] = j
+		} else {
+			prev[i] = -1
+		}
+		last[k] = i
+	}
+	return prev
+}
+
+// copyKey は交換可能なコピーを見分けるキーを返す。
+// 手札同士・場同士の同じ種類のタイルは交換しても解の意味が変わらない。
+// 解の判定（accept）があるときは、場のジョーカーの判定（jokerRule）が場のジョーカーと元のメルドの仲間を見るので、
+// 場のジョーカーは区別し、ジョーカーを含む場のメルドのタイルは同じメルドのタイル同士だけを交換可能とする。
+func copyKey(board Board, allTiles []Tile, accept bool) func(Tile) string {
+	origin := make(map[TileID]int)
+	withJoker := make(map[int]bool)
+	for i, meld := range boardMelds(board, allTiles) {
+		for _, tile := range meld {
+			origin[tile.ID] = i
+			withJoker[i] = withJoker[i] || tile.IsJoker
+		}
+	}
+	return func(t Tile) string {
+		i, ok := origin[t.ID]
+		switch {
+		case !ok:
+			return t.Notation() + "@hand"
+		case !accept || !withJoker[i]:
+			return t.Notation() + "@board"
+		case t.IsJoker:
+			return fmt.Sprintf("JK#%d", t.ID)
+		default:
+			return fmt.Sprintf("%s@%d", t.Notation(), i)
+		}
+	}
+}
+
+// lowestCopies は候補が、交換可能なコピーのうちまだ使われていない小さい位置のものから使っているかを返す。
+// 分岐中のタイル branch は除く。
+//
+// どの解も、交換可能なコピーを入れ替えれば、各段階で選ぶ候補がこの条件を満たすようにできる。
+// 分岐中のタイルを含む解のメルドについて、branch 以外のタイルを同じ種類の未使用のコピーのうち
+// 小さい位置のものと入れ替えればよく、入れ替え先はまだ選んでいないメルドのタイルなので
+// それまでに選んだ候補は変わらない。このため条件を満たさない候補を飛ばしても解を見落とさない。
+func lowestCopies(candidate candidateInfo, branch int, copies []int, used tileSet) bool {
+	if copies == nil {
+		return true
+	}
+	for _, idx := range candidate.indices {
+		if idx == branch {
+			continue
+		}
+		for x := copies[idx]; x >= 0; x = copies[x] {
+			if !used.has(x) && !candidate.mask.has(x) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// preferOrigins は同じ種類のタイルのコピーを入れ替えて、なるべく元の場のメルドの仲間と
+// 同じメルドに入るようにする。解の意味は変わらず、表示や手順が分かりやすくなる。
+// 元のメルドごとの行き先（target）は、今のコピーの割り当てによらずタイルの種類の重なりで決める。
+// 元のメルドと解のメルドの組み合わせは、丸ごと入る組を優先して重なりの合計が最大になるように割り当て（assignMax）、
+// 組にならなかった元のメルドは最も重なる解のメルドを行き先にする。
+// 種類ごとには、行き先が一致するコピーを先に割り当てる（行き先が決まっていれば、これで一致する数が最大になる）。
+// 入れ替えるのは key が同じコピー同士だけ。
+func preferOrigins(solution [][]Tile, board Board, allTiles []Tile, key func(Tile) string) [][]Tile {
+	melds := boardMelds(board, allTiles)
+	origin := make(map[TileID]int)
+	for i, meld := range melds {
+		for _, tile := range meld {
+			origin[tile.ID] = i
+		}
+	}
+
+	// weight[o][i] は元のメルド o と解のメルド i で種類が重なるタイルの数。
+	// o が丸ごと i に入れるなら、どの重なりの合計よりも大きい点を足す
+	countKinds := func(tiles []Tile) map[string]int {
+		count := make(map[string]int)
+		for _, tile := range tiles {
+			count[tile.Notation()]++
+		}
+		return count
+	}
+	solutionKinds := make([]map[string]int, len(solution))
+	for i, meld := range solution {
+		solutionKinds[i] = countKinds(meld)
+	}
+	whole := len(allTiles) + 1
+	weight := make([][]int, len(melds))
+	for o, meld := range melds {
+		weight[o] = make([]int, len(solution))
+		kinds := countKinds(meld)
+		for i := range solution {
+			for kind, n := range kinds {
+				weight[o][i] += min(n, solutionKinds[i][kind])
+			}
+			if weight[o][i] == len(meld) {
+				weight[o][i] += whole
+			}
+		}
+	}
+
+	target := assignMax(weight)
+	for o := range target {
+		if target[o] >= 0 && weight[o][target[o]] > 0 {
+			continue
+		}
+		target[o] = -1
+		for i, w := range weight[o] {
+			if w > 0 && (target[o] == -1 || w > weight[o][target[o]]) {
+				target[o] = i
+			}
+		}
+	}
+
+	type slot struct{ meld, pos int }
+	var kinds []string
+	slots := make(map[string][]slot)
+	for i, meld := range solution {
+		for k, tile := range meld {
+			kind := key(tile)
+			if _, ok := slots[kind]; !ok {
+				kinds = append(kinds, kind)
+			}
+			slots[kind] = append(slots[kind], slot{i, k})
+		}
+	}
+
+	result := make([][]Tile, len(solution))
+	for i, meld := range solution {
+		result[i] = slices.Clone(meld)
+	}
+	for _, kind := range kinds {
+		ss := slots[kind]
+		if len(ss) < 2 {
+			continue
+		}
+		var tiles []Tile
+		for _, s := range ss {
+			tiles = append(tiles, solution[s.meld][s.pos])
+		}
+
+		// 元のメルドの行き先が解のメルドと一致するタイルを先に割り当て、残りを順に割り当てる
+		assigned := make([]bool, len(tiles))
+		filled := make([]bool, len(ss))
+		for k, s := range ss {
+			for t, tile := range tiles {
+				if o, ok := origin[tile.ID]; !assigned[t] && ok && target[o] == s.meld {
+					result[s.meld][s.pos] = tile
+					assigned[t], filled[k] = true, true
+					break
+				}
+			}
+		}
+		for k, s := range ss {
+			if filled[k] {
+				continue
+			}
+			for t, tile := range tiles {
+				if !assigned[t] {
+					result[s.meld][s.pos] = tile
+					assigned[t] = true
+					break
+				}
+			}
+		}
+	}
+	return result
+}
+
+// assignMax は行と列を1対1に組み合わせ、weight の合計が最大になる組み合わせを返す（ハンガリー法）。
+// 結果は行ごとの列の番号で、列が足りず組にならなかった行は -1。
+func assignMax(weight [][]int) []int {
+	rows := len(weight)
+	cols := 0
+	for _, row := range weight {
+		cols = max(cols, len(row))
+	}
+	// 正方行列に広げ、最大化を最小化に直す。添字は1始まりで、0は番兵
+	n := max(rows, cols)
+	cost := func(i, j int) int {
+		if i <= rows && j <= len(weight[i-1]) {
+			return -weight[i-1][j-1]
+		}
+		return 0
+	}
+
+	const inf = int(^uint(0) >> 1)
+	u := make([]int, n+1)
+	v := make([]int, n+1)
+	match := make([]int, n+1) // 列ごとに組になった行
+	way := make([]int, n+1)
+	for i := 1; i <= n; i++ {
+		match[0] = i
+		j0 := 0
+		minv := slices.Repeat([]int{inf}, n+1)
+		used := make([]bool, n+1)
+		for match[j0] != 0 {
+			used[j0] = true
+			i0, delta, j1 := match[j0], inf, 0
+			for j := 1; j <= n; j++ {
+				if used[j] {
+					continue
+				}
+				if c := cost(i0, j) - u[i0] - v[j]; c < minv[j] {
+					minv[j], way[j] = c, j0
+				}
+				if minv[j] < delta {
+					delta, j1 = minv[j], j
+				}
+			}
+			for j := 0; j <= n; j++ {
+				if used[j] {
+					u[match[j]] += delta
+					v[j] -= delta
+				} else {
+					minv[j] -= delta
+				}
+			}
+			j0 = j1
+		}
+		for j0 != 0 {
+			j1 := way[j0]
+			match[j0] = match[j1]
+			j0 = j1
+		}
+	}
+
+	result := slices.Repeat([]int{-1}, rows)
+	for j := 1; j <= n; j++ {
+		if i := match[j]; i >= 1 && i <= rows && j <= cols {
+			result[i-1] = j - 1
+		}
+	}
+	return result
+}
+
+// exactCover はバックトラッキングでExact Cover問題を解く
+func exactCover(tiles []Tile, infos []candidateInfo, copies []int, accept acceptFunc, mon *monitor) ([][]Tile, bool) {
+	s := newCoverSearch(tiles, infos, copies, accept, mon)
+	// 解の判定がなければ、解があるかは残りのタイルの種類ごとの枚数だけで決まるので、
+	// 違う順に候補を選んで同じ残りになった部分局面は一度調べればよい（判定があれば memoRules に従う）
+	if s.hashes = mon.memoHashes(tiles, infos, accept); s.hashes != nil {
+		s.memo = newFailMemo(mon.memoSize)
+	}
+	if s.backtrack() {
+		return s.solution, true
+	}
+	return nil, false
+}
+
+// newCoverSearch は tiles を候補で覆う探索の状態を返す
+func newCoverSearch(tiles []Tile, infos []candidateInfo, copies []int, accept acceptFunc, mon *monitor) *coverSearch {
+	return &coverSearch{
+		candidates: infos,
+		byTile:     candidatesByTile(len(tiles), infos),
+		copies:     copies,
+		accept:     accept,
+		mon:        mon,
+		n:          len(tiles),
+		used:       newTileSet(len(tiles)),
+		solution:   make([][]Tile, 0, len(tiles)),
+	}
+}
+
+// buildCandidateInfos は各候補が使うタイルのインデックスを計算する
+func buildCandidateInfos(tiles []Tile, candidates [][]Tile) []candidateInfo {
+	// IDからインデックスへのマップを作成
+	idToIndex := make(map[TileID]int)
+	for i, tile := range tiles {
+		idToIndex[tile.ID] = i
+	}
+
+	// 各候補が使うタイルのインデックスを計算する。インデックスと集合はまとめて確保する
+	candidateInfos := make([]candidateInfo, 0, len(candidates))
+	total := 0
+	for _, candidate := range candidates {
+		total += len(candidate)
+	}
+	allIndices := make([]int, 0, total)
+	words := (len(tiles) + 63) / 64
+	masks := make(tileSet, words*len(candidates))
+
+	for i, candidate := range candidates {
+		indices := allIndices[len(allIndices) : len(allIndices) : len(allIndices)+len(candidate)]
+		mask := masks[i*words : (i+1)*words : (i+1)*words]
+		for _, tile := range candidate {
+			idx, ok := idToIndex[tile.ID]
+			if !ok {
+				break
+			}
+			indices = append(indices, idx)
+			mask.add(idx)
+		}
+
+		if len(indices) == len(candidate) {
+			allIndices = allIndices[:len(allIndices)+len(indices)]
+			candidateInfos = append(candidateInfos, candidateInfo{
+				tiles:   candidate,
+				indices: indices,
+				mask:    mask,
+			})
+		}
+	}
+	return candidateInfos
+}
+
+// candidatesByTile はタイルごとに、そのタイルを含む候補の番号を索引する
+func candidatesByTile(n int, candidates []candidateInfo) [][]int {
+	// 先に数えて、索引はまとめて確保する
+	counts := make([]int, n)
+	total := 0
+	for _, candidate := range candidates {
+		for _, idx := range candidate.indices {
+			counts[idx]++
+		}
+		total += len(candidate.indices)
+	}
+	backing := make([]int, 0, total)
+	byTile := make([][]int, n)
+	for i, count := range counts {
+		byTile[i] = backing[len(backing) : len(backing) : len(backing)+count]
+		backing = backing[:len(backing)+count]
+	}
+	for c, candidate := range candidates {
+		for _, idx := range candidate.indices {
+			byTile[idx] = append(byTile[idx], c)
+		}
+	}
+	return byTile
+}
+
+// acceptFunc は見つかった被覆を解として採用するかを判定する。nil なら常に採用する。
+type acceptFunc func(solution [][]Tile) bool
+
+// coverSearch は exactCover の探索状態
+type coverSearch struct {
+	candidates []candidateInfo
+	byTile     [][]int // タイルごとの、そのタイルを含む候補
+	copies     []int
+	accept     acceptFunc
+	mon        *monitor
+	n          int      // タイルの数
+	used       tileSet  // 選んだ候補が使っているタイル
+	solution   [][]Tile // 現在選んでいる候補。成功時はそのまま解になる
+
+	memo   *failMemo // 解のなかった部分局面。nil なら覚えない
+	hashes []memoKey // 候補ごとのハッシュ（candidateHashes）
+	key    memoKey   // 使っているタイルのハッシュ
+
+	enum *enumeration // 解をすべて列挙するときの状態。nil なら最初の解で止まる
+}
+
+// backtrack はExact Coverのバックトラッキング探索。
+// 最初の未使用タイルを含む候補を順に試す。探索中は割り当てをしない。
+func (s *coverSearch) backtrack() bool {
+	if !s.mon.visit() {
+		return false
+	}
+
+	// 全てカバーできたら成功
+	first := s.used.firstMissing(s.n)
+	if first == -1 {
+		if s.enum != nil {
+			return s.enum.leaf(s.solution)
+		}
+		return s.accept == nil || s.accept(s.solution)
+	}
+
+	if s.memo != nil {
+		hit := s.memo.contains(s.key)
+		s.mon.probe(hit)
+		if hit {
+			return false
+		}
+	}
+
+	// このタイルを含む候補を試す
+	mark := s.enum.mark()
+	for _, c := range s.byTile[first] {
+		candidate := &s.candidates[c]
+		if !s.used.disjoint(candidate.mask) || !lowestCopies(*candidate, first, s.copies, s.used) || !s.enum.allows(c, *candidate) {
+			continue
+		}
+
+		prev := s.enum.choose(c, first)
+		s.used.union(candidate.mask)
+		s.solution = append(s.solution, candidate.tiles)
+		if s.memo != nil {
+			s.key = s.key.plus(s.hashes[c])
+		}
+		if s.backtrack() {
+			return true
+		}
+
+		// 元に戻す
+		if s.memo != nil {
+			s.key = s.key.minus(s.hashes[c])
+		}
+		s.solution = s.solution[:len(s.solution)-1]
+		s.used.subtract(candidate.mask)
+		s.enum.restore(first, prev)
+		s.mon.undo()
+		if s.mon.stopped() {
+			return false
+		}
+	}
+
+	if s.memo != nil && s.enum.failed(mark) {
+		s.memo.add(s.key)
+	}
+	return false
+}
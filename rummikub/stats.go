@@ -0,0 +1,111 @@
+package rummikub
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Stats は探索の統計
+type Stats struct {
+	Candidates int           // 生成した候補メルドの数
+	Nodes      int           // 探索したノードの数
+	Backtracks int           // 選んだ候補を取り消して戻った回数
+	MemoProbes int           // 解のなかった部分局面の表を引いた回数
+	MemoHits   int           // 表で解がないと分かり、探索を省いた回数
+	Elapsed    time.Duration // 候補の生成を含む経過時間
+
+	// Infeasible は探索の前の確認（Precheck）で詰みがないと分かったときの理由。探索したときは nil
+	Infeasible *InfeasibleError
+}
+
+func (s Stats) String() string {
+	return fmt.Sprintf("candidates=%d nodes=%d backtracks=%d memo=%d/%d elapsed=%s",
+		s.Candidates, s.Nodes, s.Backtracks, s.MemoHits, s.MemoProbes, s.Elapsed)
+}
+
+// MemoHitRate は表を引いたうち、探索を省けた割合を返す
+func (s Stats) MemoHitRate() float64 {
+	if s.MemoProbes == 0 {
+		return 0
+	}
+	return float64(s.MemoHits) / float64(s.MemoProbes)
+}
+
+// checkInterval は ctx を確かめるノードの間隔
+const checkInterval = 1024
+
+// monitor は探索の打ち切りと統計の記録。nil なら何もしない
+type monitor struct {
+	ctx      context.Context
+	stats    Stats
+	err      error // 打ち切った理由（ctx.Err()）
+	memoSize int   // 解のなかった部分局面の表の大きさ。0 なら表を使わない
+	// 解の判定があるときに表を使うための情報。nil なら判定があるときは表を使わない
+	memoRules *memoRules
+}
+
+// newMonitor は ctx に従って探索を打ち切る monitor を返す
+func newMonitor(ctx context.Context) *monitor {
+	return &monitor{ctx: ctx}
+}
+
+// visit はノードを1つ数え、探索を続けてよいかを返す。ctx は checkInterval ノードごとに確かめる
+func (m *monitor) visit() bool {
+	if m == nil {
+		return true
+	}
+	if m.err != nil {
+		return false
+	}
+	m.stats.Nodes++
+	if m.stats.Nodes%checkInterval == 0 {
+		m.err = m.ctx.Err()
+	}
+	return m.err == nil
+}
+
+// undo は候補の取り消しを1回数える
+func (m *monitor) undo() {
+	if m != nil {
+		m.stats.Backtracks++
+	}
+}
+
+// memoHashes は tiles を candidates で覆う探索で、部分局面の表に使う候補ごとのハッシュを返す。
+// 表を使わないなら nil。解の判定 accept があるときは、memoRules がなければ表を使わない。
+func (m *monitor) memoHashes(tiles []Tile, candidates []candidateInfo, accept acceptFunc) []memoKey {
+	switch {
+	case m == nil || m.memoSize <= 0:
+		return nil
+	case m.memoRules != nil:
+		return m.memoRules.hashes(tiles, candidates)
+	case accept != nil:
+		return nil
+	}
+	return candidateHashes(tiles, candidates, Tile.Notation)
+}
+
+// probe は表を引いた結果を数える
+func (m *monitor) probe(hit bool) {
+	if m == nil {
+		return
+	}
+	m.stats.MemoProbes++
+	if hit {
+		m.stats.MemoHits++
+	}
+}
+
+// stopped は探索を打ち切ったかを返す
+func (m *monitor) stopped() bool {
+	return m != nil && m.err != nil
+}
+
+// SolveCheckmateContext は ctx の期限とキャンセルに従って詰み判定を行う。
+// 探索を打ち切ったときは ctx.Err() を返し、このとき詰みの有無は分からない（false を返すが「詰みなし」ではない）。
+// opts.Stats が nil でなければ探索の統計を書き込む。探索の前の確認で詰みがないと分かったときは、
+// その理由を Stats.Infeasible に書き込む（Precheck を呼び直さなくてよい）。
+func SolveCheckmateContext(ctx context.Context, gs *GameState, opts Options) (bool, []Meld, error) {
+	return findCheckmateContext(ctx, gs, opts, nil)
+}
@@ -0,0 +1,118 @@
+package rummikub
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+// expiringContext は Err を limit 回呼ばれた後から期限切れを返す context
+type expiringContext struct {
+	context.Context
+	calls, limit int
+}
+
+func (c *expiringContext) Err() error {
+	c.calls++
+	if c.calls > c.limit {
+		return context.DeadlineExceeded
+	}
+	return nil
+}
+
+func TestSolveCheckmateContext(t *testing.T) {
+	for _, engine := range []Engine{EngineBacktrack, EngineDLX} {
+		for _, p := range testPositions {
+			t.Run(engine.String()+"/"+p.name, func(t *testing.T) {
+				var stats Stats
+				opts := DefaultOptions()
+				opts.Engine = engine
+				opts.Stats = &stats
+				gs := &GameState{Board: p.board, Hand: p.hand, Opened: true}
+
+				got, solution, err := SolveCheckmateContext(context.Background(), gs, opts)
+				if err != nil {
+					t.Fatalf("SolveCheckmateContext() error = %v", err)
+				}
+				if got != p.checkmate {
+					t.Fatalf("SolveCheckmateContext() = %v, want %v", got, p.checkmate)
+				}
+				if got {
+					checkSolutionTiles(t, p.board, p.hand, solution)
+				}
+				// 探索の前の確認で詰みなしと分かる局面は探索しない
+				if (stats.Nodes == 0 && Precheck(gs, opts) == nil) || stats.Elapsed <= 0 {
+					t.Errorf("Stats = %v, want nodes and elapsed time", stats)
+				}
+				if len(p.hand.Tiles) > 0 && stats.Candidates == 0 {
+					t.Errorf("Stats = %v, want candidates", stats)
+				}
+			})
+		}
+	}
+}
+
+func TestSolveCheckmateContext_Canceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	p := findPosition(t, "SimpleRun")
+	gs := &GameState{Board: p.board, Hand: p.hand, Opened: true}
+	if got, _, err := SolveCheckmateContext(ctx, gs, DefaultOptions()); got || !errors.Is(err, context.Canceled) {
+		t.Errorf("SolveCheckmateContext() = %v, %v, want context.Canceled", got, err)
+	}
+}
+
+func TestSolveCheckmateContext_Deadline(t *testing.T) {
+	// どちらのエンジンも数万ノードを探索する局面で、途中で期限が切れる
+	board, hand := generatePosition(9, 60, 2)
+	hand.Tiles = append(hand.Tiles, R1, JK)
+	gs := &GameState{Board: board, Hand: hand, Opened: true}
+
+	for _, engine := range []Engine{EngineBacktrack, EngineDLX} {
+		var stats Stats
+		opts := DefaultOptions()
+		opts.Engine = engine
+		opts.Stats = &stats
+		ctx := &expiringContext{Context: context.Background(), limit: 2}
+
+		got, solution, err := SolveCheckmateContext(ctx, gs, opts)
+		if got || solution != nil || !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("%s: SolveCheckmateContext() = %v, %v, want context.DeadlineExceeded", engine, got, err)
+		}
+		if stats.Nodes != 2*checkInterval {
+			t.Errorf("%s: Stats.Nodes = %d, want %d", engine, stats.Nodes, 2*checkInterval)
+		}
+	}
+}
+
+// TestContexts_Deadline は解の数え上げ・列挙、場を崩さない解、最も多く出せる手の探索も期限で打ち切れることを確かめる
+func TestContexts_Deadline(t *testing.T) {
+	board, hand := generatePosition(9, 60, 2)
+	hand.Tiles = append(hand.Tiles, R1, JK)
+	gs := &GameState{Board: board, Hand: hand, Opened: true}
+	expiring := func() context.Context { return &expiringContext{Context: context.Background(), limit: 2} }
+
+	if _, err := CountSolutionsContext(expiring(), gs, DefaultOptions()); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("CountSolutionsContext() error = %v, want context.DeadlineExceeded", err)
+	}
+
+	var last error
+	for solution, err := range SolutionsContext(expiring(), gs, DefaultOptions()) {
+		if solution != nil {
+			t.Errorf("SolutionsContext() yielded %v", solution)
+		}
+		last = err
+	}
+	if !errors.Is(last, context.DeadlineExceeded) {
+		t.Errorf("SolutionsContext() error = %v, want context.DeadlineExceeded", last)
+	}
+
+	if got, _, err := SolveCheckmateMinimalDisruptionContext(expiring(), gs, DefaultOptions()); got || !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("SolveCheckmateMinimalDisruptionContext() = %v, %v, want context.DeadlineExceeded", got, err)
+	}
+
+	if _, ok, err := SolveMaxPlayContext(expiring(), gs, DefaultOptions()); ok || !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("SolveMaxPlayContext() = %v, %v, want context.DeadlineExceeded", ok, err)
+	}
+}
@@ -1,4 +1,4 @@
-package main
+package rummikub
 
 import "fmt"
 
@@ -74,6 +74,14 @@ func NewJoker() Tile {
 	}
 }
 
+// Notation は "R1" や "JK" といった色付けなしの表記を返す
+func (t Tile) Notation() string {
+	if t.IsJoker {
+		return "JK"
+	}
+	return fmt.Sprintf("%s%d", t.Color, t.Number)
+}
+
 func (t Tile) String() string {
 	if t.IsJoker {
 		return "\033[35mJK" + resetColor // 紫
@@ -0,0 +1,64 @@
+package rummikub
+
+import "math/bits"
+
+// tileSet はタイルの位置の集合を表すビット集合。
+// 公式ルールのデッキ（106枚）なら2語に収まり、探索中の演算は割り当てなしで行える。
+type tileSet []uint64
+
+// newTileSet は位置 0..n-1 を入れられる空の集合を返す
+func newTileSet(n int) tileSet {
+	return make(tileSet, (n+63)/64)
+}
+
+// has は位置 i が集合に含まれるかを返す
+func (s tileSet) has(i int) bool {
+	return s[i/64]&(1<<(i%64)) != 0
+}
+
+// add は位置 i を集合に加える
+func (s tileSet) add(i int) {
+	s[i/64] |= 1 << (i % 64)
+}
+
+// remove は位置 i を集合から除く
+func (s tileSet) remove(i int) {
+	s[i/64] &^= 1 << (i % 64)
+}
+
+// union は o の要素をすべて集合に加える
+func (s tileSet) union(o tileSet) {
+	for w := range o {
+		s[w] |= o[w]
+	}
+}
+
+// subtract は o の要素をすべて集合から除く
+func (s tileSet) subtract(o tileSet) {
+	for w := range o {
+		s[w] &^= o[w]
+	}
+}
+
+// disjoint は o と共通の要素がないかを返す
+func (s tileSet) disjoint(o tileSet) bool {
+	for w := range o {
+		if s[w]&o[w] != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// firstMissing は集合に含まれない最小の位置を返す。0..n-1 がすべて含まれていれば -1
+func (s tileSet) firstMissing(n int) int {
+	for w, word := range s {
+		if word != ^uint64(0) {
+			if i := w*64 + bits.TrailingZeros64(^word); i < n {
+				return i
+			}
+			return -1
+		}
+	}
+	return -1
+}
@@ -0,0 +1,72 @@
+package rummikub
+
+import (
+	"context"
+	"fmt"
+	"testing"
+)
+
+func TestTileSet(t *testing.T) {
+	s := newTileSet(130)
+	for _, i := range []int{0, 1, 63, 64, 129} {
+		s.add(i)
+	}
+	if !s.has(63) || !s.has(64) || s.has(2) {
+		t.Errorf("has() is wrong for %v", s)
+	}
+	if got := s.firstMissing(130); got != 2 {
+		t.Errorf("firstMissing() = %d, want 2", got)
+	}
+
+	o := newTileSet(130)
+	o.add(2)
+	o.add(128)
+	if !s.disjoint(o) {
+		t.Errorf("%v and %v should be disjoint", s, o)
+	}
+	s.union(o)
+	if s.disjoint(o) || !s.has(128) {
+		t.Errorf("union() = %v", s)
+	}
+	s.subtract(o)
+	s.remove(0)
+	if s.has(2) || s.has(128) || s.has(0) || !s.has(1) {
+		t.Errorf("subtract() and remove() = %v", s)
+	}
+
+	full := newTileSet(70)
+	for i := range 70 {
+		full.add(i)
+	}
+	if got := full.firstMissing(70); got != -1 {
+		t.Errorf("firstMissing() of a full set = %d, want -1", got)
+	}
+}
+
+// BenchmarkSolveCover は候補を生成した後の探索だけを測る。
+// 探索中は割り当てをしないので、allocs/op は候補の数で決まり、ノード数によらない。
+func BenchmarkSolveCover(b *testing.B) {
+	positions := testPositions
+	for _, size := range []int{40, 60} {
+		board, hand := generatePosition(uint64(size), size, 2)
+		positions = append(positions, testPosition{name: fmt.Sprintf("Generated%d", size), board: board, hand: hand})
+	}
+
+	for _, p := range positions {
+		tiles := collectTiles(p.board, p.hand)
+		infos := buildCandidateInfos(tiles, GenerateAllCandidatesWithRules(tiles, DefaultRules()))
+		copies := copyOrder(tiles, Tile.Notation)
+		for _, engine := range []Engine{EngineBacktrack, EngineDLX} {
+			b.Run(p.name+"/"+engine.String(), func(b *testing.B) {
+				b.ReportAllocs()
+				var nodes int
+				for b.Loop() {
+					mon := newMonitor(context.Background())
+					solveCover(engine, tiles, infos, copies, nil, mon)
+					nodes = mon.stats.Nodes
+				}
+				b.ReportMetric(float64(nodes), "nodes/op")
+			})
+		}
+	}
+}
@@ -0,0 +1,170 @@
+package rummikub
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Location はタイルの位置。Meld と Position は0始まりで、Hand が true なら Meld は使わない
+type Location struct {
+	Hand     bool
+	Meld     int
+	Position int
+}
+
+func (l Location) String() string {
+	if l.Hand {
+		return fmt.Sprintf("hand tile %d", l.Position+1)
+	}
+	return fmt.Sprintf("board meld %d tile %d", l.Meld+1, l.Position+1)
+}
+
+// InvalidTileError は読み込めないタイル、またはルールのデッキにないタイル
+type InvalidTileError struct {
+	Location Location
+	Text     string // 入力の表記
+}
+
+func (e *InvalidTileError) Error() string {
+	return fmt.Sprintf("invalid tile: %s (%s)", e.Text, e.Location)
+}
+
+// InvalidMeldError はランにもグループにもならない場のメルド。Meld は0始まりの番号で、
+// Err は理由を表す *MeldError
+type InvalidMeldError struct {
+	Meld  int
+	Tiles Meld
+	Err   error
+}
+
+func (e *InvalidMeldError) Error() string {
+	return fmt.Sprintf("invalid meld: board meld %d %v", e.Meld+1, e.Err)
+}
+
+func (e *InvalidMeldError) Unwrap() error {
+	return e.Err
+}
+
+// TileCountError は同じタイル（またはジョーカー）がデッキの枚数より多く使われていること
+type TileCountError struct {
+	Tile      Tile
+	Count     int
+	Max       int
+	Locations []Location
+}
+
+func (e *TileCountError) Error() string {
+	locations := make([]string, len(e.Locations))
+	for i, l := range e.Locations {
+		locations[i] = l.String()
+	}
+	return fmt.Sprintf("too many %s: %d (max %d) at %s", e.Tile.Notation(), e.Count, e.Max, strings.Join(locations, ", "))
+}
+
+// ValidationErrors は入力の誤りをまとめたもの。
+// errors.As で個々の InvalidTileError などを取り出せる。
+type ValidationErrors []error
+
+func (e ValidationErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = err.Error()
+	}
+	return strings.Join(messages, "\n")
+}
+
+func (e ValidationErrors) Unwrap() []error {
+	return e
+}
+
+// Validate はゲームの状態がルールに沿っているかを確かめ、誤りをすべてまとめて返す。
+// 場のメルドが有効なこと、タイルがデッキに含まれること、同じタイルがデッキの枚数を超えないことを確かめる。
+// ルールに矛盾があれば Rules.Validate の誤りだけを返す。
+func (gs *GameState) Validate(r Rules) error {
+	if err := r.Validate(); err != nil {
+		return err
+	}
+	if errs := gs.validate(r, nil); len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// tileOrigins は読み込んだタイルの入力での位置。
+// 読み込めないタイルを除くと位置がずれるので、誤りは入力での位置で報告する。
+type tileOrigins struct {
+	board  [][]int // 場のメルドごとの、各タイルの入力での位置
+	hand   []int
+	broken []bool // 読み込めないタイルを含んでいた場のメルド（メルドとしては確かめない）
+}
+
+// validate は Validate の誤りを返す。origins が nil でなければ、位置を入力での位置に直す
+func (gs *GameState) validate(r Rules, origins *tileOrigins) ValidationErrors {
+	r = r.withDefaults()
+	var errs ValidationErrors
+
+	type counted struct {
+		tile      Tile
+		locations []Location
+	}
+	var order []string
+	counts := make(map[string]*counted)
+	add := func(tile Tile, l Location) {
+		if !r.validTile(tile) {
+			errs = append(errs, &InvalidTileError{Location: l, Text: tile.Notation()})
+			return
+		}
+		key := tile.Notation()
+		if counts[key] == nil {
+			counts[key] = &counted{tile: Tile{Number: tile.Number, Color: tile.Color, IsJoker: tile.IsJoker}}
+			order = append(order, key)
+		}
+		counts[key].locations = append(counts[key].locations, l)
+	}
+
+	for i, meld := range gs.Board.Melds {
+		for k, tile := range meld {
+			if origins != nil {
+				k = origins.board[i][k]
+			}
+			add(tile, Location{Meld: i, Position: k})
+		}
+	}
+	for k, tile := range gs.Hand.Tiles {
+		if origins != nil {
+			k = origins.hand[k]
+		}
+		add(tile, Location{Hand: true, Position: k})
+	}
+
+	for i, meld := range gs.Board.Melds {
+		if origins != nil && origins.broken[i] {
+			continue
+		}
+		if _, err := meld.ResolveWith(r, false); err != nil {
+			errs = append(errs, &InvalidMeldError{Meld: i, Tiles: meld, Err: err})
+		}
+	}
+
+	for _, key := range order {
+		c := counts[key]
+		limit := r.Copies
+		if c.tile.IsJoker {
+			limit = r.jokerCount()
+		}
+		if len(c.locations) > limit {
+			errs = append(errs, &TileCountError{Tile: c.tile, Count: len(c.locations), Max: limit, Locations: c.locations})
+		}
+	}
+
+	return errs
+}
+
+// meldNotation はメルドを色付けなしの表記にする
+func meldNotation(m Meld) string {
+	notations := make([]string, len(m))
+	for i, tile := range m {
+		notations[i] = tile.Notation()
+	}
+	return "[" + strings.Join(notations, " ") + "]"
+}
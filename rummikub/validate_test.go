@@ -0,0 +1,78 @@
+package rummikub
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestParseGameState_ValidationErrors(t *testing.T) {
+	data := []byte(`{
+		"board": [["R1", "R2", "R4"], ["R1", "X1", "R3"], ["JK", "JK", "R5"]],
+		"hand": ["R1", "JK", "R14"]
+	}`)
+	_, err := ParseGameState(data)
+
+	var errs ValidationErrors
+	if !errors.As(err, &errs) {
+		t.Fatalf("ParseGameState() error = %v, want ValidationErrors", err)
+	}
+	// 読み込めない X1 と R14、無効な [R1 R2 R4]、3枚の R1 と JK。X1 を含むメルドはメルドとしては確かめない
+	want := []string{
+		"invalid tile: X1 (board meld 2 tile 2)",
+		"invalid tile: R14 (hand tile 3)",
+		"invalid meld: board meld 1",
+		"too many R1: 3 (max 2) at board meld 1 tile 1, board meld 2 tile 1, hand tile 1",
+		"too many JK: 3 (max 2) at board meld 3 tile 1, board meld 3 tile 2, hand tile 2",
+	}
+	if len(errs) != len(want) {
+		t.Fatalf("ParseGameState() returned %d errors, want %d:\n%v", len(errs), len(want), err)
+	}
+	for i, e := range errs {
+		if !strings.HasPrefix(e.Error(), want[i]) {
+			t.Errorf("error %d = %q, want %q", i, e, want[i])
+		}
+	}
+}
+
+func TestGameState_Validate(t *testing.T) {
+	gs := &GameState{
+		Board: Board{Melds: []Meld{{R1, R2, R4}, {R1, R2, R3}, {JK, JK, R5}}},
+		Hand:  Hand{Tiles: []Tile{R1, JK, NewTile(Red, 14)}},
+	}
+	err := gs.Validate(DefaultRules())
+	if err == nil {
+		t.Fatal("Validate() error = nil")
+	}
+
+	var meldErr *InvalidMeldError
+	if !errors.As(err, &meldErr) || meldErr.Meld != 0 {
+		t.Errorf("InvalidMeldError = %+v, want board meld 1", meldErr)
+	}
+	var tileErr *InvalidTileError
+	if !errors.As(err, &tileErr) || tileErr.Location != (Location{Hand: true, Position: 2}) {
+		t.Errorf("InvalidTileError = %+v, want hand tile 3", tileErr)
+	}
+
+	var counts []*TileCountError
+	for _, e := range err.(ValidationErrors) {
+		var countErr *TileCountError
+		if errors.As(e, &countErr) {
+			counts = append(counts, countErr)
+		}
+	}
+	if len(counts) != 2 {
+		t.Fatalf("TileCountErrors = %v, want R1 and JK", counts)
+	}
+	if counts[0].Tile != R1 || counts[0].Count != 3 || counts[0].Max != 2 || len(counts[0].Locations) != 3 {
+		t.Errorf("TileCountError = %+v, want 3 R1 (max 2)", counts[0])
+	}
+	if !counts[1].Tile.IsJoker || counts[1].Count != 3 {
+		t.Errorf("TileCountError = %+v, want 3 JK", counts[1])
+	}
+
+	valid := &GameState{Board: Board{Melds: []Meld{{R1, R2, R3}}}, Hand: Hand{Tiles: []Tile{R1, JK}}}
+	if err := valid.Validate(DefaultRules()); err != nil {
+		t.Errorf("Validate() error = %v", err)
+	}
+}
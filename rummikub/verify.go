@@ -0,0 +1,529 @@
+package rummikub
+
+import (
+	"fmt"
+	"slices"
+)
+
+// TileMismatchError は解で使われた枚数が場と手札の枚数と合わないタイル
+type TileMismatchError struct {
+	Tile Tile
+	Want int // 場と手札にある枚数
+	Got  int // 解で使われた枚数
+}
+
+func (e *TileMismatchError) Error() string {
+	if e.Got < e.Want {
+		return fmt.Sprintf("missing %s: used %d of %d", e.Tile.Notation(), e.Got, e.Want)
+	}
+	return fmt.Sprintf("extra %s: used %d, but only %d on board and in hand", e.Tile.Notation(), e.Got, e.Want)
+}
+
+// SolutionMeldError は解（または手を指した後の場）の中の無効なメルド。
+// Meld は0始まりの番号で、Err は理由を表す *MeldError
+type SolutionMeldError struct {
+	Meld  int
+	Tiles Meld
+	Err   error
+}
+
+func (e *SolutionMeldError) Error() string {
+	return fmt.Sprintf("invalid meld: new board meld %d %v", e.Meld+1, e.Err)
+}
+
+func (e *SolutionMeldError) Unwrap() error {
+	return e.Err
+}
+
+// RuleViolationError は解や手が初手やジョーカーの取り出しのルールを満たさないこと。
+// Rule は "opening", "joker", または手札を1枚も出していない "move"
+type RuleViolationError struct {
+	Rule string
+	Msg  string
+}
+
+func (e *RuleViolationError) Error() string {
+	return fmt.Sprintf("rule violation (%s): %s", e.Rule, e.Msg)
+}
+
+// Verify は解が詰みの解として正しいかを、ソルバーとは独立に確かめる。
+// 初手は済んでいるものとして標準ルールで確かめる。
+func Verify(board Board, hand Hand, solution []Meld) error {
+	gs := &GameState{Board: board, Hand: hand, Opened: true}
+	return VerifyWithRules(gs, solution, DefaultRules())
+}
+
+// VerifyWithRules は指定したルールで解を確かめ、誤りをすべてまとめた ValidationErrors を返す。
+// 解のタイルが場と手札のタイルと過不足なく一致すること、各メルドが有効なこと、
+// 初手とジョーカーの取り出しのルールを満たすことを確かめる。
+// 解のタイルのIDは使わない。同じ種類のタイルのどのコピーを使ったかは、ルールを満たす割り当てがあるかで判断する。
+func VerifyWithRules(gs *GameState, solution []Meld, r Rules) error {
+	r = r.withDefaults()
+	var errs ValidationErrors
+
+	b := countTiles(gs, solution)
+	for _, key := range b.order {
+		if want := b.board[key] + b.hand[key]; b.used[key] != want {
+			errs = append(errs, &TileMismatchError{Tile: b.kinds[key], Want: want, Got: b.used[key]})
+		}
+	}
+	errs = append(errs, verifyMelds(solution, r)...)
+
+	// タイルが合わなければ、コピーの割り当てが決まらないのでルールは確かめない
+	if len(errs) == 0 {
+		errs = append(errs, verifyRules(gs, solution, r)...)
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs
+}
+
+// VerifyMove は手札を出して場を after に組み替える手が正しいかを確かめ、出した手札のタイルを返す。
+// 初手は済んでいるものとして標準ルールで確かめる。
+func VerifyMove(board Board, hand Hand, after []Meld) ([]Tile, error) {
+	gs := &GameState{Board: board, Hand: hand, Opened: true}
+	return VerifyMoveWithRules(gs, after, DefaultRules())
+}
+
+// VerifyMoveWithRules は指定したルールで手を確かめ、誤りをすべてまとめた ValidationErrors を返す。
+// 元の場のタイルがすべて残っていること、増えたタイルが手札のタイルであること、手札を1枚以上出したこと、
+// 各メルドが有効なこと、初手とジョーカーの取り出しのルールを満たすことを確かめる。
+// 出した手札のタイルは手札の並び順に返す。
+func VerifyMoveWithRules(gs *GameState, after []Meld, r Rules) ([]Tile, error) {
+	r = r.withDefaults()
+	var errs ValidationErrors
+
+	b := countTiles(gs, after)
+	for _, key := range b.order {
+		switch {
+		case b.used[key] < b.board[key]:
+			errs = append(errs, &TileMismatchError{Tile: b.kinds[key], Want: b.board[key], Got: b.used[key]})
+		case b.used[key] > b.board[key]+b.hand[key]:
+			errs = append(errs, &TileMismatchError{Tile: b.kinds[key], Want: b.board[key] + b.hand[key], Got: b.used[key]})
+		}
+	}
+
+	var played []Tile
+	playedCount := make(map[string]int)
+	for _, tile := range gs.Hand.Tiles {
+		key := tile.Notation()
+		if b.used[key]-b.board[key] > playedCount[key] {
+			played = append(played, tile)
+			playedCount[key]++
+		}
+	}
+	if len(errs) == 0 && len(played) == 0 {
+		errs = append(errs, &RuleViolationError{Rule: "move", Msg: "no tile played from the hand"})
+	}
+	errs = append(errs, verifyMelds(after, r)...)
+
+	if len(errs) == 0 {
+		errs = append(errs, verifyRules(gs, after, r)...)
+	}
+
+	if len(errs) == 0 {
+		return played, nil
+	}
+	return played, errs
+}
+
+// tileBalance は局面と組み替えた後の場の、表記ごとのタイルの枚数
+type tileBalance struct {
+	order []string // 最初に現れた順
+	kinds map[string]Tile
+	board map[string]int
+	hand  map[string]int
+	used  map[string]int // 組み替えた後の場
+}
+
+// countTiles は局面と組み替えた後の場のタイルを表記ごとに数える
+func countTiles(gs *GameState, melds []Meld) tileBalance {
+	b := tileBalance{
+		kinds: make(map[string]Tile),
+		board: make(map[string]int),
+		hand:  make(map[string]int),
+		used:  make(map[string]int),
+	}
+	count := func(tile Tile, counts map[string]int) {
+		key := tile.Notation()
+		if _, ok := b.kinds[key]; !ok {
+			b.kinds[key] = Tile{Number: tile.Number, Color: tile.Color, IsJoker: tile.IsJoker}
+			b.order = append(b.order, key)
+		}
+		counts[key]++
+	}
+	for _, meld := range gs.Board.Melds {
+		for _, tile := range meld {
+			count(tile, b.board)
+		}
+	}
+	for _, tile := range gs.Hand.Tiles {
+		count(tile, b.hand)
+	}
+	for _, meld := range melds {
+		for _, tile := range meld {
+			count(tile, b.used)
+		}
+	}
+	return b
+}
+
+// verifyMelds は無効なメルドをすべて返す
+func verifyMelds(melds []Meld, r Rules) []error {
+	var errs []error
+	for i, meld := range melds {
+		if _, err := meld.ResolveWith(r, false); err != nil {
+			errs = append(errs, &SolutionMeldError{Meld: i, Tiles: meld, Err: err})
+		}
+	}
+	return errs
+}
+
+// verifyRules は解のタイルに場と手札のタイルのIDを割り当て、初手とジョーカーの取り出しのルールを確かめる。
+// ルールを満たす割り当てがあれば nil を、なければ最初の割り当てでの違反を返す。
+// 解は場のタイルをすべて含み、残りは手札のタイルでなければならない。
+// 場に残さなかった手札のタイルは出さなかったものとして扱う。
+func verifyRules(gs *GameState, solution []Meld, r Rules) []error {
+	allTiles := collectTiles(gs.Board, gs.Hand)
+	boardCount := len(allTiles) - len(gs.Hand.Tiles)
+	original := boardMelds(gs.Board, allTiles)
+
+	var checks []func(assigned [][]Tile) error
+	if !gs.Opened {
+		if !r.OpeningManipulation {
+			checks = append(checks, func(assigned [][]Tile) error {
+				for i, meld := range original {
+					if !slices.ContainsFunc(assigned, func(tiles []Tile) bool { return sameIDs(tiles, meld) }) {
+						return &RuleViolationError{Rule: "opening", Msg: fmt.Sprintf("board meld %d changed before the initial meld", i+1)}
+					}
+				}
+				return nil
+			})
+		}
+		checks = append(checks, func(assigned [][]Tile) error {
+			return verifyOpening(assigned, boardCount, r)
+		})
+	}
+	if !r.FreeJokers {
+		type boardJoker struct {
+			meld         int
+			id           TileID
+			replacements []Tile
+		}
+		var jokers []boardJoker
+		for i, meld := range original {
+			for k, tile := range meld {
+				if tile.IsJoker {
+					jokers = append(jokers, boardJoker{meld: i, id: tile.ID, replacements: meld.JokerReplacementsWith(k, r)})
+				}
+			}
+		}
+		if len(jokers) > 0 {
+			checks = append(checks, func(assigned [][]Tile) error {
+				for _, j := range jokers {
+					if err := verifyJoker(assigned, original[j.meld], j.id, j.replacements, boardCount, r, j.meld); err != nil {
+						return err
+					}
+				}
+				return nil
+			})
+		}
+	}
+	if len(checks) == 0 {
+		return nil
+	}
+
+	// 判定が見分けるのは、タイルが手札か場か、ジョーカーを含む場のメルドのどれのタイルか、どの場のジョーカーかだけ。
+	// それぞれをクラスとし、同じ種類でクラスも同じコピーはどれを割り当てても同じとする
+	class := make(map[TileID]int) // 手札は0、ジョーカーを含まない場のメルドは1
+	classes := 2
+	for _, meld := range original {
+		c := 1
+		if !r.FreeJokers && slices.ContainsFunc(meld, func(t Tile) bool { return t.IsJoker }) {
+			c = classes
+			classes++
+		}
+		for _, tile := range meld {
+			class[tile.ID] = c
+			if c > 1 && tile.IsJoker {
+				class[tile.ID] = classes
+				classes++
+			}
+		}
+	}
+
+	assigned := make([][]Tile, len(solution))
+	fixed := make([][]bool, len(solution)) // 割り当てが決まったタイル
+	for i, meld := range solution {
+		assigned[i] = slices.Clone(meld)
+		fixed[i] = make([]bool, len(meld))
+	}
+	taken := make(map[TileID]bool)
+	if !gs.Opened && !r.OpeningManipulation {
+		// 元の場のメルドは、それぞれ同じ種類のタイルからなる解のメルドに割り当てる。
+		// 同じ種類のタイルからなる解のメルドはどれに割り当てても判定は変わらないので、前から順に選ぶ
+		matched := make([]bool, len(solution))
+		for _, meld := range original {
+			for i, m := range solution {
+				if matched[i] || !sameKinds(m, meld) {
+					continue
+				}
+				matched[i] = true
+				for k, tile := range m {
+					n := slices.IndexFunc(meld, func(t Tile) bool { return !taken[t.ID] && t.Notation() == tile.Notation() })
+					assigned[i][k], fixed[i][k] = meld[n], true
+					taken[meld[n].ID] = true
+				}
+				break
+			}
+		}
+	}
+
+	// 場のタイルを先に並べるので、先頭から解で使われた枚数だけ取れば場のタイルはすべて含まれる。
+	// 出さなかった手札のタイルはどのコピーでも同じ。
+	slots := make(map[string][][2]int)
+	for i, meld := range solution {
+		for k, tile := range meld {
+			if !fixed[i][k] {
+				slots[tile.Notation()] = append(slots[tile.Notation()], [2]int{i, k})
+			}
+		}
+	}
+	var order []string
+	ids := make(map[string][]TileID)
+	for _, tile := range allTiles {
+		key := tile.Notation()
+		if taken[tile.ID] || len(ids[key]) == len(slots[key]) {
+			continue
+		}
+		if ids[key] == nil {
+			order = append(order, key)
+		}
+		ids[key] = append(ids[key], tile.ID)
+	}
+
+	// クラスの異なるコピーがある種類だけ、スロットへのクラスの並べ方を試す
+	type kind struct {
+		slots        [][2]int
+		arrangements [][]TileID
+	}
+	var mixed []kind
+	for _, key := range order {
+		arrangements := classArrangements(ids[key], class)
+		if len(arrangements) > 1 {
+			mixed = append(mixed, kind{slots: slots[key], arrangements: arrangements})
+			continue
+		}
+		for n, slot := range slots[key] {
+			assigned[slot[0]][slot[1]] = allTiles[arrangements[0][n]]
+			fixed[slot[0]][slot[1]] = true
+		}
+	}
+
+	// 判定の結果は、解のメルドごとに含まれるクラスの集合と、ジョーカーの位置だけで決まる。
+	// 種類ごとに並べ方を広げながら、この2つが同じになる割り当ては最初の1つだけ残す
+	type partial struct {
+		sets   []tileSet // 解のメルドごとのクラスの集合
+		choice []int     // mixed の種類ごとに選んだ並べ方
+	}
+	start := partial{sets: make([]tileSet, len(solution))}
+	for i, meld := range assigned {
+		start.sets[i] = newTileSet(classes)
+		for k, tile := range meld {
+			if fixed[i][k] {
+				start.sets[i].add(class[tile.ID])
+			}
+		}
+	}
+	states := []partial{start}
+	for _, kd := range mixed {
+		var next []partial
+		seen := make(map[string]bool)
+		for _, st := range states {
+			for a, arrangement := range kd.arrangements {
+				sets := slices.Clone(st.sets)
+				for n, slot := range kd.slots {
+					sets[slot[0]] = slices.Clone(sets[slot[0]])
+					sets[slot[0]].add(class[arrangement[n]])
+				}
+				choice := append(slices.Clip(st.choice), a)
+				// ジョーカーは同じメルドの中の位置でも意味が変わるので、並べ方ごとに区別する
+				key := fmt.Sprint(sets)
+				if allTiles[arrangement[0]].IsJoker {
+					key += fmt.Sprint(choice)
+				}
+				if !seen[key] {
+					seen[key] = true
+					next = append(next, partial{sets: sets, choice: choice})
+				}
+			}
+		}
+		states = next
+	}
+
+	var first []error
+	for _, st := range states {
+		for k, kd := range mixed {
+			for n, slot := range kd.slots {
+				assigned[slot[0]][slot[1]] = allTiles[kd.arrangements[st.choice[k]][n]]
+			}
+		}
+
+		var violations []error
+		for _, check := range checks {
+			if err := check(assigned); err != nil {
+				violations = append(violations, err)
+			}
+		}
+		if len(violations) == 0 {
+			return nil
+		}
+		if first == nil {
+			first = violations
+		}
+	}
+	return first
+}
+
+// classArrangements は ids を同じ数のスロットに並べる方法のうち、クラスの並びが異なるものを返す。
+// 同じクラスのタイルは ids の順に使う。
+func classArrangements(ids []TileID, class map[TileID]int) [][]TileID {
+	var byClass [][]TileID
+	index := make(map[int]int)
+	for _, id := range ids {
+		c, ok := index[class[id]]
+		if !ok {
+			c = len(byClass)
+			index[class[id]] = c
+			byClass = append(byClass, nil)
+		}
+		byClass[c] = append(byClass[c], id)
+	}
+
+	var result [][]TileID
+	used := make([]int, len(byClass))
+	current := make([]TileID, 0, len(ids))
+	var walk func()
+	walk = func() {
+		if len(current) == len(ids) {
+			result = append(result, slices.Clone(current))
+			return
+		}
+		for c, group := range byClass {
+			if used[c] == len(group) {
+				continue
+			}
+			current = append(current, group[used[c]])
+			used[c]++
+			walk()
+			used[c]--
+			current = current[:len(current)-1]
+		}
+	}
+	walk()
+	return result
+}
+
+// sameKinds はタイルの組が meld と同じ種類のタイルからなるかを返す
+func sameKinds(tiles []Tile, meld Meld) bool {
+	count := make(map[string]int)
+	for _, tile := range meld {
+		count[tile.Notation()]++
+	}
+	for _, tile := range tiles {
+		count[tile.Notation()]--
+	}
+	for _, n := range count {
+		if n != 0 {
+			return false
+		}
+	}
+	return len(tiles) == len(meld)
+}
+
+// verifyOpening は初手で、手札のタイルだけで作ったメルドの合計点が r.InitialMeldPoints 以上かを確かめる。
+// 手札を1枚も出していなければ初手ではないので確かめない。
+func verifyOpening(assigned [][]Tile, boardCount int, r Rules) error {
+	played := false
+	points := 0
+	for _, tiles := range assigned {
+		fromBoard := slices.ContainsFunc(tiles, func(t Tile) bool { return int(t.ID) < boardCount })
+		if !fromBoard {
+			points += Meld(tiles).PointsWith(r)
+		}
+		if slices.ContainsFunc(tiles, func(t Tile) bool { return int(t.ID) >= boardCount }) {
+			played = true
+		}
+	}
+	if played && points < r.InitialMeldPoints {
+		return &RuleViolationError{Rule: "opening", Msg: fmt.Sprintf("initial meld has %d points from the hand, needs %d", points, r.InitialMeldPoints)}
+	}
+	return nil
+}
+
+// verifyJoker は場の meldIndex 番目のメルド original にあったジョーカー id の扱いが公式ルールに沿っているかを確かめる。
+// ジョーカーは、元のメルドのタイルと一緒に replacements のいずれかの代わりのまま残るか、
+// replacements のいずれかのタイルを元のメルドのタイルと同じメルドに入れて取り出し、
+// 元のメルドのタイルを含まない、手札のタイルを含むメルドで使わなければならない。
+func verifyJoker(assigned [][]Tile, original Meld, id TileID, replacements []Tile, boardCount int, r Rules, meldIndex int) error {
+	isMate := func(t Tile) bool {
+		return t.ID != id && slices.ContainsFunc(original, func(o Tile) bool { return o.ID == t.ID })
+	}
+	isReplacement := func(t Tile) bool {
+		return !t.IsJoker && slices.ContainsFunc(replacements, func(rep Tile) bool {
+			return rep.Color == t.Color && rep.Number == t.Number
+		})
+	}
+	violation := func(msg string) error {
+		return &RuleViolationError{Rule: "joker", Msg: fmt.Sprintf("joker of board meld %d %s", meldIndex+1, msg)}
+	}
+
+	var meld Meld
+	pos := -1
+	for _, tiles := range assigned {
+		if k := slices.IndexFunc(tiles, func(t Tile) bool { return t.ID == id }); k >= 0 {
+			meld, pos = Meld(tiles), k
+			break
+		}
+	}
+	if pos < 0 {
+		return violation("is missing from the new board")
+	}
+
+	if slices.ContainsFunc(meld, isMate) {
+		// 元のメルドのタイルと一緒なら、同じタイルの代わりのままでなければならない
+		for _, rep := range replacements {
+			swapped := slices.Clone(meld)
+			swapped[pos] = rep
+			if swapped.IsValidWith(r) {
+				return nil
+			}
+		}
+		return violation("stands for a different tile with its original meld")
+	}
+
+	if !slices.ContainsFunc(meld, func(t Tile) bool { return int(t.ID) >= boardCount }) {
+		return violation("taken without using it in a meld with hand tiles")
+	}
+	for _, tiles := range assigned {
+		if slices.ContainsFunc(tiles, isMate) && slices.ContainsFunc(tiles, isReplacement) {
+			return nil
+		}
+	}
+	return violation("taken without replacing it")
+}
+
+// sameIDs はタイルの組がちょうど meld のタイル（IDで比べる）からなるかを返す
+func sameIDs(tiles []Tile, meld Meld) bool {
+	if len(tiles) != len(meld) {
+		return false
+	}
+	for _, tile := range tiles {
+		if !slices.ContainsFunc(meld, func(t Tile) bool { return t.ID == tile.ID }) {
+			return false
+		}
+	}
+	return true
+}
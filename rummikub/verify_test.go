@@ -0,0 +1,207 @@
+package rummikub
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestVerifyWithRules(t *testing.T) {
+	run := Board{Melds: []Meld{{R1, R2, R3}}}
+	jokers := Board{Melds: []Meld{{R3, R4, R5, JK}, {B7, Y7, K7, JK}}}
+	// 同じランが2つずつある場。出どころの違うコピーをすべて並べ替えると、種類ごとに2倍ずつ増える
+	reds := Meld{R1, R2, R3, R4, R5, R6, R7, R8, R9, R10, R11, R12}
+	blues := Meld{B1, B2, B3, B4, B5, B6, B7, B8, B9, B10, B11, B12, B13}
+	duplicates := Board{Melds: []Meld{reds, reds, blues, {B1, B2, B3, B4, B5, B6, B7, B8, B9, B10, B11, B12, JK}}}
+
+	tests := []struct {
+		name     string
+		gs       *GameState
+		solution []Meld
+		want     []string // 誤りの種類: "tile", "meld", "opening", "joker"
+	}{
+		{"valid", &GameState{Board: run, Hand: Hand{Tiles: []Tile{R4, B5, B6, B7}}, Opened: true},
+			[]Meld{{R1, R2, R3, R4}, {B5, B6, B7}}, nil},
+		{"missing tile", &GameState{Board: run, Hand: Hand{Tiles: []Tile{R4, B5}}, Opened: true},
+			[]Meld{{R1, R2, R3, R4}}, []string{"tile"}},
+		{"extra tile", &GameState{Board: run, Hand: Hand{Tiles: []Tile{R4}}, Opened: true},
+			[]Meld{{R1, R2, R3, R4, R5}}, []string{"tile"}},
+		{"invalid meld", &GameState{Board: run, Hand: Hand{Tiles: []Tile{B4}}, Opened: true},
+			[]Meld{{R1, R2, R3, B4}}, []string{"meld"}},
+		{"board changed before opening", &GameState{Board: run, Hand: Hand{Tiles: []Tile{R4, B10, B11, B12}}},
+			[]Meld{{R1, R2, R3, R4}, {B10, B11, B12}}, []string{"opening"}},
+		{"not enough points", &GameState{Board: run, Hand: Hand{Tiles: []Tile{B5, B6, B7}}},
+			[]Meld{{R1, R2, R3}, {B5, B6, B7}}, []string{"opening"}},
+		{"opening", &GameState{Board: run, Hand: Hand{Tiles: []Tile{B10, B11, B12}}},
+			[]Meld{{R1, R2, R3}, {B10, B11, B12}}, nil},
+		{"joker taken without replacement", &GameState{Board: jokers, Hand: Hand{Tiles: []Tile{B9, Y9}}, Opened: true},
+			[]Meld{{R3, R4, R5}, {B7, Y7, K7, JK}, {B9, Y9, JK}}, []string{"joker"}},
+		{"jokers assigned by origin", &GameState{Board: jokers, Hand: Hand{Tiles: []Tile{R6, B9, Y9}}, Opened: true},
+			[]Meld{{R3, R4, R5, R6}, {B7, Y7, K7, JK}, {B9, Y9, JK}}, nil},
+		{"duplicates with a board joker", &GameState{Board: duplicates, Hand: Hand{Tiles: []Tile{B13, Y13, K13}}, Opened: true},
+			[]Meld{reds, reds, blues, blues, {JK, Y13, K13}}, nil},
+		{"duplicates with a misused joker", &GameState{Board: duplicates, Hand: Hand{Tiles: []Tile{B13}}, Opened: true},
+			[]Meld{reds, reds[:10], blues, blues, {R11, R12, JK}}, []string{"joker"}},
+		{"duplicates before opening", &GameState{Board: duplicates, Hand: Hand{Tiles: []Tile{Y11, Y12, Y13}}},
+			[]Meld{reds, reds, blues, duplicates.Melds[3], {Y11, Y12, Y13}}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := VerifyWithRules(tt.gs, tt.solution, DefaultRules())
+			if err == nil {
+				if tt.want != nil {
+					t.Errorf("VerifyWithRules() = nil, want %v", tt.want)
+				}
+				return
+			}
+
+			var errs ValidationErrors
+			if !errors.As(err, &errs) {
+				t.Fatalf("VerifyWithRules() error = %v, want ValidationErrors", err)
+			}
+			var got []string
+			for _, e := range errs {
+				var tileErr *TileMismatchError
+				var meldErr *SolutionMeldError
+				var ruleErr *RuleViolationError
+				switch {
+				case errors.As(e, &tileErr):
+					got = append(got, "tile")
+				case errors.As(e, &meldErr):
+					got = append(got, "meld")
+				case errors.As(e, &ruleErr):
+					got = append(got, ruleErr.Rule)
+				default:
+					got = append(got, e.Error())
+				}
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("VerifyWithRules() = %v, want %v", err, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("VerifyWithRules() = %v, want %v", err, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestVerify_TileMismatch(t *testing.T) {
+	board := Board{Melds: []Meld{{R1, R2, R3}}}
+	hand := Hand{Tiles: []Tile{R4}}
+
+	err := Verify(board, hand, []Meld{{R1, R2, R3}, {B1, B2, B3}})
+	var mismatch *TileMismatchError
+	if !errors.As(err, &mismatch) || mismatch.Tile != R4 || mismatch.Want != 1 || mismatch.Got != 0 {
+		t.Errorf("TileMismatchError = %+v, want R4 used 0 of 1", mismatch)
+	}
+	if n := len(err.(ValidationErrors)); n != 4 {
+		t.Errorf("Verify() returned %d errors, want R4 and B1-B3:\n%v", n, err)
+	}
+}
+
+func TestVerifyMoveWithRules(t *testing.T) {
+	board := Board{Melds: []Meld{{R3, R4, R5, JK}, {B9, Y9, K9}}}
+
+	tests := []struct {
+		name   string
+		hand   []Tile
+		opened bool
+		after  []Meld
+		played int
+		want   []string // 誤りの種類: "tile", "meld", "opening", "joker", "move"
+	}{
+		{"extend", []Tile{R7, B1}, true,
+			[]Meld{{R3, R4, R5, JK, R7}, {B9, Y9, K9}}, 1, nil},
+		{"joker moved without hand tiles", []Tile{R6}, true,
+			[]Meld{{R3, R4, R5, R6}, {B9, Y9, K9, JK}}, 1, []string{"joker"}},
+		{"invalid new meld", []Tile{R6, R9, B10}, true,
+			[]Meld{{R3, R4, R5, R6}, {B9, Y9, K9}, {R9, JK, B10}}, 3, []string{"meld"}},
+		{"retrieve joker", []Tile{R6, R9, R10}, true,
+			[]Meld{{R3, R4, R5, R6}, {B9, Y9, K9}, {R9, R10, JK}}, 3, nil},
+		{"board tile taken back", []Tile{R6}, true,
+			[]Meld{{R3, R4, R5, R6}, {B9, Y9, K9}}, 1, []string{"tile"}},
+		{"tile not in hand", []Tile{R6}, true,
+			[]Meld{{R3, R4, R5, JK}, {B9, Y9, K9, R9}}, 0, []string{"tile"}},
+		{"nothing played", []Tile{R6}, true,
+			[]Meld{{B9, Y9, K9}, {R3, R4, R5, JK}}, 0, []string{"move"}},
+		{"opening needs points", []Tile{B1, B2, B3}, false,
+			[]Meld{{R3, R4, R5, JK}, {B9, Y9, K9}, {B1, B2, B3}}, 3, []string{"opening"}},
+		{"opening", []Tile{B10, B11, B12, B1}, false,
+			[]Meld{{R3, R4, R5, JK}, {B9, Y9, K9}, {B10, B11, B12}}, 3, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gs := &GameState{Board: board, Hand: Hand{Tiles: tt.hand}, Opened: tt.opened}
+			played, err := VerifyMoveWithRules(gs, tt.after, DefaultRules())
+			if len(played) != tt.played {
+				t.Errorf("VerifyMoveWithRules() played %v, want %d tiles", played, tt.played)
+			}
+
+			var got []string
+			var errs ValidationErrors
+			if errors.As(err, &errs) {
+				for _, e := range errs {
+					var tileErr *TileMismatchError
+					var meldErr *SolutionMeldError
+					var ruleErr *RuleViolationError
+					switch {
+					case errors.As(e, &tileErr):
+						got = append(got, "tile")
+					case errors.As(e, &meldErr):
+						got = append(got, "meld")
+					case errors.As(e, &ruleErr):
+						got = append(got, ruleErr.Rule)
+					}
+				}
+			} else if err != nil {
+				t.Fatalf("VerifyMoveWithRules() error = %v, want ValidationErrors", err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("VerifyMoveWithRules() = %v, want %v", err, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("VerifyMoveWithRules() = %v, want %v", err, tt.want)
+				}
+			}
+		})
+	}
+}
+
+// TestVerify_SolverSolutions はソルバーの解が、ソルバーとは独立な Verify で正しいと確かめられることを確かめる
+func TestVerify_SolverSolutions(t *testing.T) {
+	jokers := Board{Melds: []Meld{{R3, R4, R5, JK}, {B7, Y7, K7, JK}}}
+	for _, p := range testPositions {
+		t.Run(p.name, func(t *testing.T) {
+			ok, solution := SolveCheckmate(p.board, p.hand)
+			if ok != p.checkmate {
+				t.Fatalf("SolveCheckmate() = %v, want %v", ok, p.checkmate)
+			}
+			if ok {
+				if err := Verify(p.board, p.hand, solution); err != nil {
+					t.Errorf("Verify(%v) = %v", solution, err)
+				}
+			}
+		})
+	}
+
+	// 初手とジョーカーの取り出しのルールがある局面
+	for _, gs := range []*GameState{
+		{Board: Board{Melds: []Meld{{R1, R2, R3}}}, Hand: Hand{Tiles: []Tile{B10, B11, B12}}},
+		{Board: Board{Melds: []Meld{{R1, R2, R3}}}, Hand: Hand{Tiles: []Tile{R10, R11, R12, R13}}},
+		{Board: jokers, Hand: Hand{Tiles: []Tile{R6, B9, Y9}}, Opened: true},
+		{Board: jokers, Hand: Hand{Tiles: []Tile{R7, B9, Y9}}, Opened: true},
+	} {
+		ok, solution := SolveCheckmateWithOptions(gs, Options{Rules: DefaultRules()})
+		if !ok {
+			t.Errorf("SolveCheckmateWithOptions(%v, %v) found no checkmate", gs.Board, gs.Hand)
+			continue
+		}
+		if err := VerifyWithRules(gs, solution, DefaultRules()); err != nil {
+			t.Errorf("VerifyWithRules(%v) = %v", solution, err)
+		}
+	}
+}